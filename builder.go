@@ -0,0 +1,54 @@
+package set
+
+// Builder collects Add and Remove operations for a Set and applies them all at
+// once with Commit. It's not safe for concurrent use, but the Set it belongs to
+// is never locked until Commit is called.
+type Builder struct {
+	s   *Set
+	ops map[interface{}]bool // item -> true if added, false if removed
+}
+
+// Builder returns a new Builder that commits its operations to s.
+func (s *Set) Builder() *Builder {
+	return &Builder{
+		s:   s,
+		ops: make(map[interface{}]bool),
+	}
+}
+
+// Add records the specified items to be added to the set on Commit.
+func (b *Builder) Add(items ...interface{}) {
+	for _, item := range items {
+		b.ops[item] = true
+	}
+}
+
+// Remove records the specified items to be removed from the set on Commit.
+func (b *Builder) Remove(items ...interface{}) {
+	for _, item := range items {
+		b.ops[item] = false
+	}
+}
+
+// Commit applies the recorded operations to the set while holding its lock
+// once, so other goroutines see either none or all of them. The last
+// operation recorded for an item wins. The Builder is reset afterwards and can
+// be reused.
+func (b *Builder) Commit() {
+	if len(b.ops) == 0 {
+		return
+	}
+
+	c := b.s.lock()
+	defer c.unlock()
+
+	for item, add := range b.ops {
+		if add {
+			c.add(item)
+		} else {
+			c.remove(item)
+		}
+	}
+
+	b.ops = make(map[interface{}]bool)
+}
@@ -0,0 +1,65 @@
+package set
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2")
+
+	b := s.Builder()
+	b.Add("3", "4")
+	b.Remove("1", "3")
+	b.Add("1")
+
+	if s.Size() != 2 {
+		t.Error("Builder: the set should not change before Commit")
+	}
+
+	b.Commit()
+	if s.Size() != 3 || !s.Has("1", "2", "4") {
+		t.Errorf("Builder: the set should have the committed items, got %s", s)
+	}
+}
+
+func TestBuilder_atomic(t *testing.T) {
+	const n = 10000
+
+	s := newTS()
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if size := s.Size(); size != 0 && size != n {
+					t.Errorf("Builder: readers should not see a partially loaded set, got size %d", size)
+					return
+				}
+			}
+		}()
+	}
+
+	b := s.Builder()
+	for i := 0; i < n; i++ {
+		b.Add(i)
+	}
+	b.Commit()
+
+	close(done)
+	wg.Wait()
+
+	if s.Size() != n {
+		t.Errorf("Builder: the set size should be %d", n)
+	}
+}
@@ -0,0 +1,66 @@
+package set
+
+// CoSet defines a thread safe complement set: it contains every possible item
+// except the excluded ones. It's useful to model deny lists. Its operations
+// with a Set follow from De Morgan's laws:
+//
+//	co.Intersection(s) is s without the excluded items, a Set
+//	co.Union(s)        excludes the excluded items that are not in s, a CoSet
+//	co.IntersectionCo(o) excludes the items excluded by either, a CoSet
+//	co.UnionCo(o)        excludes the items excluded by both, a CoSet
+type CoSet struct {
+	excluded *Set
+}
+
+// NewCoSet creates a new CoSet that contains everything but the given items.
+func NewCoSet(excluded ...interface{}) *CoSet {
+	s := newTS()
+	s.Add(excluded...)
+	return &CoSet{excluded: s}
+}
+
+// Has reports whether item is in the set, i.e. whether it's not excluded.
+func (c *CoSet) Has(item interface{}) bool {
+	return !c.excluded.Contains(item)
+}
+
+// Exclude removes the specified items from the set.
+func (c *CoSet) Exclude(items ...interface{}) {
+	c.excluded.Add(items...)
+}
+
+// Excluded returns a copy of the excluded items.
+func (c *CoSet) Excluded() *Set {
+	return c.excluded.Clone()
+}
+
+// Intersection returns a new Set with the items of s that are not excluded.
+func (c *CoSet) Intersection(s Interface) *Set {
+	u := newTS()
+	for _, item := range s.List() {
+		if c.Has(item) {
+			u.m[item] = keyExists
+		}
+	}
+	return u
+}
+
+// Union returns a new CoSet that contains everything in c or s, i.e. it
+// excludes the items excluded by c that are not in s.
+func (c *CoSet) Union(s Interface) *CoSet {
+	return &CoSet{excluded: c.excluded.RemoveWhere(func(item interface{}) bool {
+		return s.Has(item)
+	})}
+}
+
+// IntersectionCo returns a new CoSet that contains everything in both c and
+// o, i.e. it excludes the items excluded by either of them.
+func (c *CoSet) IntersectionCo(o *CoSet) *CoSet {
+	return &CoSet{excluded: c.excluded.Clone().MergeAll(o.excluded)}
+}
+
+// UnionCo returns a new CoSet that contains everything in c or o, i.e. it
+// excludes the items excluded by both of them.
+func (c *CoSet) UnionCo(o *CoSet) *CoSet {
+	return &CoSet{excluded: c.excluded.RetainWhere(o.excluded.Contains)}
+}
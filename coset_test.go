@@ -0,0 +1,51 @@
+package set
+
+import "testing"
+
+func TestCoSet_Has(t *testing.T) {
+	c := NewCoSet("a")
+
+	if !c.Has("b") {
+		t.Error("CoSet: items that are not excluded should exist")
+	}
+
+	if c.Has("a") {
+		t.Error("CoSet: excluded items should not exist")
+	}
+
+	c.Exclude("b")
+	if c.Has("b") || c.Excluded().Size() != 2 {
+		t.Error("CoSet: newly excluded items should not exist")
+	}
+}
+
+func TestCoSet_Intersection(t *testing.T) {
+	c := NewCoSet("a", "b")
+	s := newNonTS()
+	s.Add("a", "c", "d")
+
+	u := c.Intersection(s)
+	if u.Size() != 2 || !u.Has("c", "d") {
+		t.Errorf("Intersection: got %s, want [c, d]", u)
+	}
+
+	x := c.Union(s)
+	if !x.Has("a") || x.Has("b") || !x.Has("z") {
+		t.Errorf("Union: should only exclude b, got %s", x.Excluded())
+	}
+}
+
+func TestCoSet_Co(t *testing.T) {
+	c := NewCoSet("a", "b")
+	o := NewCoSet("b", "c")
+
+	i := c.IntersectionCo(o)
+	if i.Has("a") || i.Has("b") || i.Has("c") || !i.Has("d") {
+		t.Errorf("IntersectionCo: should exclude a, b and c, got %s", i.Excluded())
+	}
+
+	u := c.UnionCo(o)
+	if !u.Has("a") || u.Has("b") || !u.Has("c") {
+		t.Errorf("UnionCo: should only exclude b, got %s", u.Excluded())
+	}
+}
@@ -0,0 +1,169 @@
+package set
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hashItem returns a 64-bit hash of item. Items that are equal as map keys
+// hash to the same value, and the type is part of the hash so that int(1) and
+// int64(1) hash differently. See encodeItem for when the result is the same
+// across processes.
+func hashItem(item interface{}) uint64 {
+	h := fnv.New64a()
+	h.Write(encodeItem(nil, item))
+	return mix64(h.Sum64())
+}
+
+// mix64 is the splitmix64 finalizer. It spreads the bits of FNV, which are
+// poorly distributed for short inputs that only differ slightly.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// encodeItem appends the canonical encoding of item to dst: its type, see
+// typeID, prefixed with its length as a uvarint, followed by its value. Items that are
+// equal as map keys have the same encoding, e.g. 0.0 and -0.0. The encoding
+// only depends on the values, so it's the same in every process, except for
+// items that are or contain pointers or channels, which are encoded by their
+// address.
+func encodeItem(dst []byte, item interface{}) []byte {
+	if item == nil {
+		return appendString(dst, "nil")
+	}
+
+	v := reflect.ValueOf(item)
+	dst = appendString(dst, typeID(v.Type()))
+	return encodeValue(dst, v)
+}
+
+// typeID returns a name for t that, unlike t.String(), includes the import
+// paths of the named types t is made of, so types of the same name in
+// different packages, e.g. a/model.ID and b/model.ID, get different names.
+func typeID(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name() // predeclared, e.g. int
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeID(t.Elem()))
+	case reflect.Pointer:
+		return "*" + typeID(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeID(t.Elem())
+	case reflect.Map:
+		return "map[" + typeID(t.Key()) + "]" + typeID(t.Elem())
+	case reflect.Chan:
+		return t.ChanDir().String() + " " + typeID(t.Elem())
+	case reflect.Struct:
+		fields := make([]string, t.NumField())
+		for i := range fields {
+			f := t.Field(i)
+			// unexported field names are qualified by their package
+			fields[i] = fmt.Sprintf("%s %s %s %q", f.PkgPath, f.Name, typeID(f.Type), f.Tag)
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}"
+	}
+	return t.String() // e.g. funcs or interfaces, which can't be items
+}
+
+// encodeValue appends the encoding of v to dst. Numbers and bools are
+// appended as a string with their length, and arrays and structs as their
+// elements or fields in order.
+func encodeValue(dst []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Bool:
+		return appendString(dst, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendString(dst, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendString(dst, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return appendString(dst, formatFloat(v.Float(), v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		c, bits := v.Complex(), v.Type().Bits()/2
+		dst = appendString(dst, formatFloat(real(c), bits))
+		return appendString(dst, formatFloat(imag(c), bits))
+	case reflect.String:
+		return appendString(dst, v.String())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			dst = encodeValue(dst, v.Index(i))
+		}
+		return dst
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			dst = encodeValue(dst, v.Field(i))
+		}
+		return dst
+	case reflect.Interface:
+		if v.IsNil() {
+			return appendString(dst, "nil")
+		}
+		dst = appendString(dst, typeID(v.Elem().Type()))
+		return encodeValue(dst, v.Elem())
+	}
+
+	// pointers, channels and unsafe pointers are compared by address
+	return appendString(dst, fmt.Sprintf("%#x", v.Pointer()))
+}
+
+// formatFloat formats f with the given precision, with -0 formatted like 0 as
+// they are equal.
+func formatFloat(f float64, bits int) string {
+	if f == 0 {
+		f = 0 // -0 == 0, so this turns -0 into 0
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+// appendString appends str prefixed with its length to dst.
+func appendString(dst []byte, str string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(str)))
+	return append(dst, str...)
+}
+
+// CanonicalBytes returns a deterministic encoding of s, suitable for hashing
+// into a content address. Equal sets always have the same encoding, regardless
+// of the order their items were added in. The encoding is the number of items
+// followed by the encoded items sorted bytewise. Each item is encoded as its
+// type name, including the import paths of named types, followed by its
+// value: numbers, bools and strings as their string form, and arrays and
+// structs as their elements or fields in order. Every string is prefixed with
+// its length as a uvarint.
+//
+// Items are encoded by value, so the encoding is the same in every process,
+// except for items that are or contain pointers or channels. These are
+// encoded by their address, which is only meaningful within one process.
+func (s *Set) CanonicalBytes() []byte {
+	list := s.List()
+
+	items := make([][]byte, len(list))
+	for i, item := range list {
+		items[i] = encodeItem(nil, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return bytes.Compare(items[i], items[j]) < 0
+	})
+
+	b := binary.AppendUvarint(nil, uint64(len(items)))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
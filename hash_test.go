@@ -0,0 +1,92 @@
+package set
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestSet_CanonicalBytes(t *testing.T) {
+	s := newTS()
+	s.Add("a", 1, int64(1), 2.5, "b", true)
+
+	u := newTS()
+	for _, item := range []interface{}{true, "b", 2.5, int64(1), 1, "a"} {
+		u.Add(item)
+	}
+
+	if !bytes.Equal(s.CanonicalBytes(), u.CanonicalBytes()) {
+		t.Error("CanonicalBytes: equal sets should have the same encoding")
+	}
+
+	u.Remove(int64(1))
+	u.Add(int32(1))
+	if bytes.Equal(s.CanonicalBytes(), u.CanonicalBytes()) {
+		t.Error("CanonicalBytes: items of different types should have a different encoding")
+	}
+
+	if !bytes.Equal(newTS().CanonicalBytes(), []byte{0}) {
+		t.Error("CanonicalBytes: an empty set should be encoded as a zero count")
+	}
+}
+
+func TestSet_CanonicalBytes_equalItems(t *testing.T) {
+	type point struct {
+		X, Y float64
+		Tag  interface{}
+	}
+
+	s := newTS()
+	s.Add(0.0, complex(0, 0), point{0, 1, 0.0}, [2]float32{0, 1})
+	u := newTS()
+	negZero := math.Copysign(0, -1)
+	u.Add(negZero, complex(negZero, negZero), point{negZero, 1, negZero}, [2]float32{float32(negZero), 1})
+
+	if !s.IsEqual(u) {
+		t.Fatal("CanonicalBytes: sets with 0 and -0 should be equal")
+	}
+	if !bytes.Equal(s.CanonicalBytes(), u.CanonicalBytes()) {
+		t.Error("CanonicalBytes: 0 and -0 should have the same encoding")
+	}
+	if hashItem(0.0) != hashItem(negZero) {
+		t.Error("hashItem: 0 and -0 should have the same hash")
+	}
+
+	// values are encoded, not their Go-syntax, so the types of fields matter
+	a, b := newTS(), newTS()
+	a.Add(point{1, 2, 3})
+	b.Add(point{1, 2, int64(3)})
+	if bytes.Equal(a.CanonicalBytes(), b.CanonicalBytes()) {
+		t.Error("CanonicalBytes: fields of different types should have a different encoding")
+	}
+}
+
+func TestTypeID(t *testing.T) {
+	v1 := reflect.TypeOf(rand.Rand{})
+	v2 := reflect.TypeOf(randv2.Rand{})
+	if v1.String() != v2.String() {
+		t.Fatalf("test types should have the same name, got %s and %s", v1, v2)
+	}
+
+	tests := []struct {
+		a, b reflect.Type
+	}{
+		{v1, v2},
+		{reflect.PointerTo(v1), reflect.PointerTo(v2)},
+		{reflect.ArrayOf(2, v1), reflect.ArrayOf(2, v2)},
+		{reflect.TypeOf(struct{ R *rand.Rand }{}), reflect.TypeOf(struct{ R *randv2.Rand }{})},
+	}
+
+	for _, tt := range tests {
+		if typeID(tt.a) == typeID(tt.b) {
+			t.Errorf("typeID: %s of different packages should differ, got %s", tt.a, typeID(tt.a))
+		}
+	}
+
+	if got := typeID(reflect.TypeOf([2]int{})); got != "[2]int" {
+		t.Errorf("typeID: got %s, want [2]int", got)
+	}
+}
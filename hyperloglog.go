@@ -0,0 +1,72 @@
+package set
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// hllPrecision is the number of hash bits used to select a register. With
+// 2^14 registers the standard error of the estimate is about 0.81%.
+const hllPrecision = 14
+
+// HyperLogLogSet defines a thread safe set that only estimates its number of
+// distinct items with the HyperLogLog algorithm. It uses a fixed amount of
+// memory (16KB) no matter how many items are added, but it can't tell which
+// items were added. Use it instead of Set for streams that are too large to be
+// stored.
+type HyperLogLogSet struct {
+	registers []uint8
+	l         sync.Mutex
+}
+
+// NewHyperLogLogSet creates and initializes a new HyperLogLogSet.
+func NewHyperLogLogSet() *HyperLogLogSet {
+	return &HyperLogLogSet{
+		registers: make([]uint8, 1<<hllPrecision),
+	}
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *HyperLogLogSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		x := hashItem(item)
+		i := x >> (64 - hllPrecision)
+		rho := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+		if rho > s.registers[i] {
+			s.registers[i] = rho
+		}
+	}
+}
+
+// EstimateSize returns the estimated number of distinct items added to s.
+func (s *HyperLogLogSet) EstimateSize() uint64 {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	m := float64(len(s.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range s.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+
+	// use linear counting for small cardinalities
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(estimate + 0.5)
+}
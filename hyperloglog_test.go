@@ -0,0 +1,25 @@
+package set
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestHyperLogLogSet(t *testing.T) {
+	// three times the standard error of the estimate
+	const maxError = 3 * 1.04 / 128
+
+	for _, n := range []int{0, 100, 10000, 200000} {
+		s := NewHyperLogLogSet()
+		for i := 0; i < n; i++ {
+			s.Add("user" + strconv.Itoa(i))
+			s.Add("user" + strconv.Itoa(i)) // duplicates don't count
+		}
+
+		got := float64(s.EstimateSize())
+		if diff := math.Abs(got - float64(n)); diff > maxError*float64(n) {
+			t.Errorf("EstimateSize: got %v for %d distinct items", got, n)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package set
+
+import (
+	"strings"
+	"sync"
+)
+
+// internPool holds the canonical copy of every string added to an interned
+// set. It's shared by all interned sets and only grows: entries are never
+// freed, even when no set holds the string anymore.
+//
+// unique.Make would let the GC reclaim unused entries, but it only keeps a
+// string canonical while a unique.Handle to it is alive. Sets store the
+// strings themselves, so equal strings added after a GC would get a new copy
+// and stop sharing their data.
+var internPool = struct {
+	sync.Mutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// intern returns the canonical copy of str, storing a copy of it if it's the
+// first time it's seen. str is copied so that the pool doesn't keep a larger
+// string alive that str may be a substring of.
+func intern(str string) string {
+	internPool.Lock()
+	defer internPool.Unlock()
+
+	if v, ok := internPool.m[str]; ok {
+		return v
+	}
+	str = strings.Clone(str)
+	internPool.m[str] = str
+	return str
+}
+
+// NewInterned creates and initializes a new threadsafe Set that interns its
+// string items. Equal strings added to any interned set share the same backing
+// data, which saves memory when many sets hold the same strings. The trade-off
+// is that Add takes a global lock for string items and the pool of interned
+// strings only grows, so it should only be used for a bounded set of values.
+func NewInterned() *Set {
+	s := newTS()
+	s.interned = true
+	return s
+}
+
+// normalize returns the item as it should be stored in s.
+func (s *Set) normalize(item interface{}) interface{} {
+	if str, ok := item.(string); ok && s.interned {
+		return intern(str)
+	}
+	return item
+}
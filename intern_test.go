@@ -0,0 +1,102 @@
+package set
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestNewInterned(t *testing.T) {
+	// build the strings at runtime so they don't share the same literal
+	a := strings.Repeat("tag", 2)
+	b := strings.Repeat("tag", 2)
+	if unsafe.StringData(a) == unsafe.StringData(b) {
+		t.Fatal("test strings should have different backing data")
+	}
+
+	s := NewInterned()
+	s.Add(a)
+	u := NewInterned()
+	u.Add(b)
+
+	x, y := s.Pop().(string), u.Pop().(string)
+	if x != "tagtag" || unsafe.StringData(x) != unsafe.StringData(y) {
+		t.Error("NewInterned: equal strings in interned sets should share the same data")
+	}
+
+	r := NewInterned()
+	r.Merge(New(NonThreadSafe))
+	c := strings.Repeat("tag", 2)
+	m := New(NonThreadSafe)
+	m.Add(c)
+	r.Merge(m)
+	if z := r.Pop().(string); unsafe.StringData(z) != unsafe.StringData(x) {
+		t.Error("NewInterned: merged strings should be interned")
+	}
+}
+
+func TestNewInterned_derived(t *testing.T) {
+	s := NewInterned()
+	s.Add("a")
+	nts := New(NonThreadSafe)
+	nts.Add("b")
+
+	stable, volatile := s.StableVolatile(nts)
+	derived := map[string]Interface{
+		"Copy":         s.Copy(),
+		"Clone":        s.Clone(),
+		"DeepCopy":     s.DeepCopy(func(item interface{}) interface{} { return item }),
+		"Union":        Union(s, nts),
+		"Difference":   Difference(s, nts),
+		"Intersection": Intersection(s, nts),
+		"UnionAll":     UnionAll(s, nts),
+		"MissingFrom":  s.MissingFrom(nts),
+		"Stable":       stable,
+		"Volatile":     volatile,
+	}
+
+	for name, u := range derived {
+		if !u.(*Set).interned {
+			t.Errorf("%s: derived sets should be interned", name)
+		}
+	}
+
+	c := s.Clone()
+	c.Remove("a")
+	c.Add(string([]byte{'a'}))
+	if x := c.Pop().(string); unsafe.StringData(x) != unsafe.StringData(intern("a")) {
+		t.Error("Clone: items added to a clone should be interned")
+	}
+
+	// items taken from the other set must be interned as well
+	other := New(NonThreadSafe)
+	other.Add(string([]byte{'b'}))
+	_, volatile = s.StableVolatile(other)
+	volatile.Remove("a")
+	results := map[string]*Set{
+		"MissingFrom":    s.MissingFrom(other),
+		"StableVolatile": volatile,
+	}
+	for name, u := range results {
+		if x := u.Pop().(string); unsafe.StringData(x) != unsafe.StringData(intern("b")) {
+			t.Errorf("%s: items of the other set should be interned", name)
+		}
+	}
+
+	if Union(nts, s).(*Set).interned {
+		t.Error("Union: the mode should be taken from the first set")
+	}
+}
+
+func TestIntern_substring(t *testing.T) {
+	large := strings.Repeat("x", 1<<10) + "intern-substring"
+	sub := large[len(large)-len("intern-substring"):]
+
+	s := NewInterned()
+	s.Add(sub)
+
+	v := s.Pop().(string)
+	if v != sub || unsafe.StringData(v) == unsafe.StringData(sub) {
+		t.Error("NewInterned: a substring should be copied, not keep the larger string alive")
+	}
+}
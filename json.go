@@ -0,0 +1,156 @@
+package set
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// DecodeJSONStream reads a JSON array from r and returns a new Set with its
+// elements. Elements are decoded and added one at a time, so the whole array
+// is never held in memory. As with encoding/json, numbers are decoded as
+// float64.
+func DecodeJSONStream(r io.Reader) (*Set, error) {
+	return DecodeJSONTyped(r, reflect.TypeOf((*interface{})(nil)).Elem())
+}
+
+// DecodeJSONTyped is like DecodeJSONStream, but decodes the elements into
+// items of type elemType, like UnmarshalJSONTyped does. E.g. numbers decoded
+// with reflect.TypeOf(0) are added as int and not as float64.
+func DecodeJSONTyped(r io.Reader, elemType reflect.Type) (*Set, error) {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+
+	s := newTS()
+	for dec.More() {
+		item := reflect.New(elemType)
+		if err := dec.Decode(item.Interface()); err != nil {
+			return nil, err
+		}
+
+		if err := s.AddSafe(item.Elem().Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(dec, ']'); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// expectDelim reads the next token from dec and returns an error if it's not
+// the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("set: expected %q in JSON input, got %v", delim, tok)
+	}
+	return nil
+}
+
+// UnmarshalJSONTyped decodes the JSON array in data into items of type
+// elemType and adds them to s. Unlike decoding into interface{} values, this
+// keeps the declared type, e.g. numbers decoded with reflect.TypeOf(0) are
+// added as int and not as float64.
+func (s *Set) UnmarshalJSONTyped(data []byte, elemType reflect.Type) error {
+	slice := reflect.New(reflect.SliceOf(elemType))
+	if err := json.Unmarshal(data, slice.Interface()); err != nil {
+		return err
+	}
+
+	v := slice.Elem()
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+
+	return s.AddSafe(items...)
+}
+
+// MarshalJSONSorted returns s encoded as a JSON array with the items in the
+// order defined by DefaultLess. Equal sets are always encoded to the same
+// bytes, so the result can be used for caching or hashing.
+func (s *Set) MarshalJSONSorted() ([]byte, error) {
+	return json.Marshal(s.SortedList())
+}
+
+// MarshalJSON implements json.Marshaler. s is encoded as a JSON array of its
+// items, in no particular order; use MarshalJSONSorted for a stable output. An
+// empty set, or a nil one if the method is called directly, is encoded as [].
+func (s *Set) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.List())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It replaces the items of s with
+// the elements of the JSON array in data. As with encoding/json, numbers are
+// decoded as float64; use UnmarshalJSONTyped to keep another type. If data is
+// not a valid array or has elements that are not comparable, such as nested
+// arrays or objects, s is left untouched.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	items, err := unmarshalItems(data)
+	if err != nil {
+		return err
+	}
+
+	m := make(map[interface{}]struct{}, len(items))
+	for _, item := range items {
+		m[s.normalize(item)] = keyExists
+	}
+
+	c := s.lock()
+	defer c.unlock()
+
+	c.reset(m)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, see (*Set).MarshalJSON.
+func (s *set) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.List())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see (*Set).UnmarshalJSON.
+func (s *set) UnmarshalJSON(data []byte) error {
+	items, err := unmarshalItems(data)
+	if err != nil {
+		return err
+	}
+
+	s.m = make(map[interface{}]struct{}, len(items))
+	for _, item := range items {
+		s.m[item] = keyExists
+	}
+	return nil
+}
+
+// unmarshalItems decodes the JSON array in data and returns its elements. It
+// returns an error if any of them can't be a set item.
+func unmarshalItems(data []byte) ([]interface{}, error) {
+	var items []interface{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if !isComparable(item) {
+			return nil, fmt.Errorf("set: item of type %T is not comparable", item)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,231 @@
+package set
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSONStream(t *testing.T) {
+	const n = 100000
+
+	r, w := io.Pipe()
+	go func() {
+		io.WriteString(w, "[")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			io.WriteString(w, `"item`+strconv.Itoa(i%(n/2))+`"`)
+		}
+		io.WriteString(w, "]")
+		w.Close()
+	}()
+
+	s, err := DecodeJSONStream(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Size() != n/2 {
+		t.Errorf("DecodeJSONStream: set size should be %d, got %d", n/2, s.Size())
+	}
+
+	if !s.Has("item0", "item49999") {
+		t.Error("DecodeJSONStream: decoded items are not available in the set")
+	}
+}
+
+func TestDecodeJSONStream_invalid(t *testing.T) {
+	inputs := []string{
+		``,
+		`{"a": 1}`,
+		`["a", "b"`,
+		`["a", [1, 2]]`,
+	}
+
+	for _, in := range inputs {
+		if _, err := DecodeJSONStream(strings.NewReader(in)); err == nil {
+			t.Errorf("DecodeJSONStream: %q should return an error", in)
+		}
+	}
+}
+
+func TestDecodeJSONTyped(t *testing.T) {
+	const n = 100000
+
+	r, w := io.Pipe()
+	go func() {
+		io.WriteString(w, "[")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			io.WriteString(w, strconv.Itoa(i))
+		}
+		io.WriteString(w, "]")
+		w.Close()
+	}()
+
+	s, err := DecodeJSONTyped(r, reflect.TypeOf(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Size() != n {
+		t.Errorf("DecodeJSONTyped: set size should be %d, got %d", n, s.Size())
+	}
+
+	if !s.Has(0, n-1) || s.Has(float64(0)) {
+		t.Error("DecodeJSONTyped: items should be decoded as int")
+	}
+
+	if _, err := DecodeJSONTyped(strings.NewReader(`[1, "a"]`), reflect.TypeOf(0)); err == nil {
+		t.Error("DecodeJSONTyped: elements of the wrong type should return an error")
+	}
+}
+
+func TestSet_UnmarshalJSONTyped(t *testing.T) {
+	data := []byte(`[1, 2, 3, 3]`)
+
+	s := newTS()
+	if err := s.UnmarshalJSONTyped(data, reflect.TypeOf(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Size() != 3 || !s.Has(1, 2, 3) || s.Has(1.0) {
+		t.Errorf("UnmarshalJSONTyped: items should be ints, got %s", s)
+	}
+
+	u := newTS()
+	if err := u.UnmarshalJSONTyped(data, reflect.TypeOf(0.0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Size() != 3 || !u.Has(1.0, 2.0, 3.0) || u.Has(1) {
+		t.Errorf("UnmarshalJSONTyped: items should be float64s, got %s", u)
+	}
+
+	if err := s.UnmarshalJSONTyped([]byte(`[1.5]`), reflect.TypeOf(0)); err == nil {
+		t.Error("UnmarshalJSONTyped: decoding a float into an int should return an error")
+	}
+
+	if err := s.UnmarshalJSONTyped([]byte(`[[1]]`), reflect.TypeOf([]int{})); err == nil {
+		t.Error("UnmarshalJSONTyped: decoding into an uncomparable type should return an error")
+	}
+}
+
+func TestSet_MarshalJSONSorted(t *testing.T) {
+	s := newTS()
+	s.Add("b", "a", 3, 1, 2.5)
+	u := newTS()
+	u.Add(2.5, 1, "a", 3, "b")
+
+	data, err := s.MarshalJSONSorted()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `[1,3,2.5,"a","b"]` {
+		t.Errorf("MarshalJSONSorted: got %s", data)
+	}
+
+	for i := 0; i < 10; i++ {
+		other, err := u.MarshalJSONSorted()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(other) != string(data) {
+			t.Fatalf("MarshalJSONSorted: equal sets should be encoded the same, got %s and %s", data, other)
+		}
+	}
+}
+
+func TestSet_MarshalJSON(t *testing.T) {
+	s := newTS()
+	s.Add("a", "b", 1.5)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := newTS()
+	u.Add("x")
+	if err := json.Unmarshal(data, u); err != nil {
+		t.Fatal(err)
+	}
+
+	if !u.IsEqual(s) {
+		t.Errorf("MarshalJSON: %s should round-trip, got %s", data, u)
+	}
+
+	for _, empty := range []*Set{newTS(), nil} {
+		if data, err := empty.MarshalJSON(); err != nil || string(data) != "[]" {
+			t.Errorf("MarshalJSON: an empty set should be encoded as [], got %s, %v", data, err)
+		}
+	}
+
+	var v struct{ Tags *Set }
+	if err := json.Unmarshal([]byte(`{"Tags": ["a", 1]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Tags.Size() != 2 || !v.Tags.Has("a", float64(1)) {
+		t.Errorf("UnmarshalJSON: got %s, want [1, a]", v.Tags)
+	}
+}
+
+func TestSet_UnmarshalJSON_invalid(t *testing.T) {
+	inputs := []string{
+		`{"a": 1}`,
+		`["a", "b"`,
+		`["a", [1, 2]]`,
+		`["a", {"b": 1}]`,
+	}
+
+	for _, in := range inputs {
+		s := newTS()
+		s.Add("x")
+
+		if err := s.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("UnmarshalJSON: %q should return an error", in)
+		}
+		if s.Size() != 1 || !s.Has("x") {
+			t.Errorf("UnmarshalJSON: %q should leave the set untouched, got %s", in, s)
+		}
+	}
+}
+
+func TestInterface_JSON(t *testing.T) {
+	for _, typ := range []SetType{ThreadSafe, NonThreadSafe} {
+		s := New(typ)
+
+		data, err := json.Marshal(s)
+		if err != nil || string(data) != "[]" {
+			t.Errorf("%s: an empty set should be encoded as [], got %s, %v", typ, data, err)
+		}
+
+		s.Add("a", "b", 1.5)
+		data, err = json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := New(typ)
+		u.Add("x")
+		if err := json.Unmarshal(data, u); err != nil {
+			t.Fatal(err)
+		}
+		if !u.IsEqual(s) {
+			t.Errorf("%s: %s should round-trip, got %s", typ, data, u)
+		}
+
+		if err := json.Unmarshal([]byte(`["a", [1]]`), u); err == nil || !u.IsEqual(s) {
+			t.Errorf("%s: invalid items should return an error and leave the set untouched", typ)
+		}
+	}
+}
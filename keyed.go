@@ -0,0 +1,152 @@
+package set
+
+import "sync"
+
+// KeyedSet defines a thread safe set whose items are identified by a key that
+// is derived from each item. It can hold items that are not comparable, such
+// as structs with slice or map fields, as long as their key is comparable.
+type KeyedSet struct {
+	m     map[interface{}]interface{} // key -> item
+	keyFn func(item interface{}) interface{}
+	l     sync.RWMutex
+}
+
+// NewKeyed creates and initializes a new KeyedSet. keyFn returns the identity
+// of an item and must return a comparable value. Two items with the same key
+// are considered the same item.
+func NewKeyed(keyFn func(item interface{}) interface{}) *KeyedSet {
+	return &KeyedSet{
+		m:     make(map[interface{}]interface{}),
+		keyFn: keyFn,
+	}
+}
+
+// Add includes the specified items (one or more) to the set. If an item with
+// the same key already exists it's overwritten by the new item.
+func (s *KeyedSet) Add(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		s.m[s.keyFn(item)] = item
+	}
+}
+
+// Remove deletes the items with the same keys as the specified items.
+func (s *KeyedSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		delete(s.m, s.keyFn(item))
+	}
+}
+
+// Has looks for the existence of items with the same keys as the items
+// passed. It returns false if nothing is passed. For multiple items it returns
+// true only if all of the items exist.
+func (s *KeyedSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	has := true
+	for _, item := range items {
+		if _, has = s.m[s.keyFn(item)]; !has {
+			break
+		}
+	}
+	return has
+}
+
+// Get returns the stored item with the given key and whether it exists.
+func (s *KeyedSet) Get(key interface{}) (interface{}, bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	item, ok := s.m[key]
+	return item, ok
+}
+
+// Size returns the number of items in a set.
+func (s *KeyedSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.m)
+}
+
+// IsEmpty reports whether the set is empty.
+func (s *KeyedSet) IsEmpty() bool {
+	return s.Size() == 0
+}
+
+// Clear removes all items from the set.
+func (s *KeyedSet) Clear() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.m = make(map[interface{}]interface{})
+}
+
+// Each traverses the items in the set, calling the provided function for each
+// set member. Traversal will continue until all items in the set have been
+// visited, or if the closure returns false.
+func (s *KeyedSet) Each(f func(item interface{}) bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for _, item := range s.m {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// List returns a slice of all items, as they were added.
+func (s *KeyedSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	list := make([]interface{}, 0, len(s.m))
+	for _, item := range s.m {
+		list = append(list, item)
+	}
+	return list
+}
+
+// Merge adds all items of t to s. Items of t overwrite the items in s with the
+// same key.
+func (s *KeyedSet) Merge(t *KeyedSet) {
+	s.Add(t.List()...)
+}
+
+// MergeResolve is like Merge, but when both s and t hold an item with the same
+// key, resolve is called with both items and its result is stored instead.
+// resolve is called while holding the lock of s, so it must not call any
+// methods of s.
+func (s *KeyedSet) MergeResolve(t *KeyedSet, resolve func(existing, incoming interface{}) interface{}) {
+	items := t.List()
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		key := s.keyFn(item)
+		if existing, ok := s.m[key]; ok {
+			item = resolve(existing, item)
+		}
+		s.m[key] = item
+	}
+}
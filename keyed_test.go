@@ -0,0 +1,95 @@
+package set
+
+import "testing"
+
+type keyedUser struct {
+	id     int
+	groups []string
+}
+
+func userKey(item interface{}) interface{} {
+	return item.(keyedUser).id
+}
+
+func TestKeyedSet(t *testing.T) {
+	s := NewKeyed(userKey)
+	s.Add(
+		keyedUser{id: 1, groups: []string{"admin"}},
+		keyedUser{id: 2, groups: []string{"dev", "ops"}},
+	)
+
+	if s.Size() != 2 {
+		t.Error("KeyedSet: the set size should be two")
+	}
+
+	if !s.Has(keyedUser{id: 1}, keyedUser{id: 2}) {
+		t.Error("KeyedSet: items should be found by their key")
+	}
+
+	// same key, different item overwrites
+	s.Add(keyedUser{id: 1, groups: []string{"guest"}})
+	if s.Size() != 2 {
+		t.Error("KeyedSet: adding an item with an existing key should not grow the set")
+	}
+
+	item, ok := s.Get(1)
+	if !ok || item.(keyedUser).groups[0] != "guest" {
+		t.Error("KeyedSet: adding an item with an existing key should overwrite it")
+	}
+
+	s.Remove(keyedUser{id: 2})
+	if s.Has(keyedUser{id: 2}) || s.Size() != 1 {
+		t.Error("KeyedSet: removed item should not exist")
+	}
+
+	if list := s.List(); len(list) != 1 || list[0].(keyedUser).id != 1 {
+		t.Error("KeyedSet: List should return the stored items")
+	}
+}
+
+func TestKeyedSet_Merge(t *testing.T) {
+	s := NewKeyed(userKey)
+	s.Add(keyedUser{id: 1}, keyedUser{id: 2})
+	u := NewKeyed(userKey)
+	u.Add(keyedUser{id: 2}, keyedUser{id: 3})
+
+	s.Merge(u)
+	if s.Size() != 3 || !s.Has(keyedUser{id: 1}, keyedUser{id: 2}, keyedUser{id: 3}) {
+		t.Error("Merge: the set doesn't have all items in it.")
+	}
+}
+
+func TestKeyedSet_MergeResolve(t *testing.T) {
+	type record struct {
+		id, version int
+	}
+	key := func(item interface{}) interface{} { return item.(record).id }
+
+	s := NewKeyed(key)
+	s.Add(record{1, 1}, record{2, 5}, record{3, 1})
+	u := NewKeyed(key)
+	u.Add(record{2, 3}, record{3, 2}, record{4, 1})
+
+	var conflicts []int
+	s.MergeResolve(u, func(existing, incoming interface{}) interface{} {
+		conflicts = append(conflicts, existing.(record).id)
+		if incoming.(record).version > existing.(record).version {
+			return incoming
+		}
+		return existing
+	})
+
+	if len(conflicts) != 2 {
+		t.Errorf("MergeResolve: resolve should be called for the two conflicting keys, got %v", conflicts)
+	}
+
+	want := map[int]int{1: 1, 2: 5, 3: 2, 4: 1}
+	if s.Size() != len(want) {
+		t.Errorf("MergeResolve: the set size should be %d", len(want))
+	}
+	for id, version := range want {
+		if item, ok := s.Get(id); !ok || item.(record).version != version {
+			t.Errorf("MergeResolve: item %d should have version %d, got %v", id, version, item)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package set
+
+import (
+	"math"
+	"reflect"
+)
+
+// NormalizeNumber returns the canonical form of numeric items, so that
+// numbers of different types that represent the same value are equal items.
+// Integers of any type and floats without a fractional part become int if
+// they fit into it, other floats become float64. Items that are not numbers
+// are returned unchanged.
+func NormalizeNumber(item interface{}) interface{} {
+	v := reflect.ValueOf(item)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n >= math.MinInt && n <= math.MaxInt {
+			return int(n)
+		}
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n := v.Uint(); n <= math.MaxInt {
+			return int(n)
+		}
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f == math.Trunc(f) && f >= math.MinInt && f < math.MaxInt {
+			return int(f)
+		}
+		return f
+	}
+	return item
+}
@@ -0,0 +1,46 @@
+package set
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := []struct {
+		in, want interface{}
+	}{
+		{1, 1},
+		{int8(-1), -1},
+		{int64(1), 1},
+		{uint16(7), 7},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{2.0, 2},
+		{float32(2), 2},
+		{2.5, 2.5},
+		{float32(0.5), 0.5},
+		{"1", "1"},
+		{nil, nil},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeNumber(tt.in); got != tt.want {
+			t.Errorf("NormalizeNumber(%#v): got %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSet_UnionNormalized(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2, 3)
+	u := newTS()
+	u.Add(int64(2), int64(3), int64(4), 4.0, "5")
+
+	if Union(s, u).Size() != 8 {
+		t.Fatal("Union: numbers of different types should be different items")
+	}
+
+	r := s.UnionNormalized(u)
+	if r.Size() != 5 || !r.Has(1, 2, 3, 4, "5") {
+		t.Errorf("UnionNormalized: got %s, want [1, 2, 3, 4, 5]", r)
+	}
+}
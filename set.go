@@ -2,14 +2,28 @@
 // a generic set data structure. In the threadsafe set, safety encompasses all
 // operations on one set. Operations on multiple sets are consistent in that
 // the elements of each set used was valid at exactly one point in time
-// between the start and the end of the operation.
+// between the start and the end of the operation. Package level operations on
+// multiple sets return a threadsafe set if any of their inputs is threadsafe,
+// with the mode (e.g. interned) of the first set.
+//
+// An empty set is a regular, non-nil set with zero items. Sets never become
+// nil: removing all items, popping the last item or calling Clear() leaves an
+// empty set that can still be used. Methods must not be called on nil sets,
+// unless their documentation says otherwise; package level functions like
+// Equal() accept them.
 package set
 
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
 // SetType denotes which type of set is created. ThreadSafe or NonThreadSafe
 type SetType int
 
 const (
-	ThreadSafe = iota
+	ThreadSafe SetType = iota
 	NonThreadSafe
 )
 
@@ -41,28 +55,122 @@ type Interface interface {
 	Copy() Interface
 	Merge(s Interface)
 	Separate(s Interface)
+	ThreadSafe() bool
 }
 
 // helpful to not write everywhere struct{}{}
 var keyExists = struct{}{}
 
-// New creates and initalizes a new Set interface. Its single parameter
-// denotes the type of set to create. Either ThreadSafe or
-// NonThreadSafe. The default is ThreadSafe.
-func New(settype SetType) Interface {
-	if settype == NonThreadSafe {
-		return newNonTS()
+// New creates and initalizes a new Set interface configured by the given
+// options. A SetType is an option too, so New(ThreadSafe) or
+// New(NonThreadSafe) can be used to choose the type of set to create. The
+// default is ThreadSafe.
+func New(opts ...Option) Interface {
+	o := options{threadSafe: true}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+
+	if !o.threadSafe {
+		s := newNonTS()
+		s.m = make(map[interface{}]struct{}, o.capacity)
+		return s
+	}
+
+	s := newTS()
+	s.m = make(map[interface{}]struct{}, o.capacity)
+	s.interned = o.interned
+	return s
+}
+
+// Option configures a set created by New.
+type Option interface {
+	apply(o *options)
+}
+
+// options holds the configuration of a new set.
+type options struct {
+	threadSafe bool
+	capacity   int
+	interned   bool
+}
+
+// optionFunc implements Option for a func.
+type optionFunc func(o *options)
+
+func (f optionFunc) apply(o *options) { f(o) }
+
+func (s SetType) apply(o *options) { o.threadSafe = s != NonThreadSafe }
+
+// WithThreadSafe sets whether the new set is threadsafe. It's the same as
+// passing ThreadSafe or NonThreadSafe.
+func WithThreadSafe(threadSafe bool) Option {
+	return optionFunc(func(o *options) { o.threadSafe = threadSafe })
+}
+
+// WithCapacity allocates space for n items upfront.
+func WithCapacity(n int) Option {
+	return optionFunc(func(o *options) { o.capacity = n })
+}
+
+// WithInterned interns the strings added to the new set, see NewInterned().
+// It's ignored for non-threadsafe sets.
+func WithInterned() Option {
+	return optionFunc(func(o *options) { o.interned = true })
+}
+
+// Equal reports whether a and b contain the same items. Unlike the IsEqual()
+// method either of them can be nil, a nil set is treated like an empty set.
+func Equal(a, b Interface) bool {
+	switch {
+	case isNil(a) && isNil(b):
+		return true
+	case isNil(a):
+		return b.IsEmpty()
+	case isNil(b):
+		return a.IsEmpty()
+	}
+	return a.IsEqual(b)
+}
+
+// isNil reports whether s is nil or a nil pointer to one of the set
+// implementations.
+func isNil(s Interface) bool {
+	switch v := s.(type) {
+	case nil:
+		return true
+	case *Set:
+		return v == nil
+	case *SetNonTS:
+		return v == nil
+	}
+	return false
+}
+
+// newFor returns a new empty set for the result of an operation on the given
+// sets. The result is threadsafe if any of the sets is threadsafe, so results
+// of mixed operations are never less safe than their inputs. If the first set
+// is a Set, the result has its mode, e.g. interned; the modes of the other
+// sets are ignored.
+func newFor(sets ...Interface) Interface {
+	for _, set := range sets {
+		if set.ThreadSafe() {
+			if first, ok := sets[0].(*Set); ok {
+				return first.empty()
+			}
+			return newTS()
+		}
 	}
-	return newTS()
+	return newNonTS()
 }
 
 // Union is the merger of multiple sets. It returns a new set with all the
 // elements present in all the sets that are passed.
 //
-// The dynamic type of the returned set is determined by the first passed set's
-// implementation of the New() method.
+// The returned set is threadsafe if any of the passed sets is threadsafe.
 func Union(set1, set2 Interface, sets ...Interface) Interface {
-	u := set1.Copy()
+	u := newFor(append([]Interface{set1, set2}, sets...)...)
+	u.Merge(set1)
 	set2.Each(func(item interface{}) bool {
 		u.Add(item)
 		return true
@@ -77,11 +185,37 @@ func Union(set1, set2 Interface, sets ...Interface) Interface {
 	return u
 }
 
+// UnionTagged is like Union, but the sets are named and it also returns the
+// names of the sets each item of the union came from. The names in origin are
+// sorted.
+func UnionTagged(sets map[string]Interface) (result *Set, origin map[interface{}][]string) {
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result = newTS()
+	origin = make(map[interface{}][]string)
+	for _, name := range names {
+		sets[name].Each(func(item interface{}) bool {
+			result.Add(item)
+			origin[item] = append(origin[item], name)
+			return true
+		})
+	}
+
+	return result, origin
+}
+
 // Difference returns a new set which contains items which are in in the first
 // set but not in the others. Unlike the Difference() method you can use this
 // function separately with multiple sets.
+//
+// The returned set is threadsafe if any of the passed sets is threadsafe.
 func Difference(set1, set2 Interface, sets ...Interface) Interface {
-	s := set1.Copy()
+	s := newFor(append([]Interface{set1, set2}, sets...)...)
+	s.Merge(set1)
 	s.Separate(set2)
 	for _, set := range sets {
 		s.Separate(set) // seperate is thread safe
@@ -90,6 +224,8 @@ func Difference(set1, set2 Interface, sets ...Interface) Interface {
 }
 
 // Intersection returns a new set which contains items that only exist in all given sets.
+//
+// The returned set is threadsafe if any of the passed sets is threadsafe.
 func Intersection(set1, set2 Interface, sets ...Interface) Interface {
 	all := Union(set1, set2, sets...)
 	result := Union(set1, set2, sets...)
@@ -109,8 +245,153 @@ func Intersection(set1, set2 Interface, sets ...Interface) Interface {
 	return result
 }
 
+// UnionAll is like Union, but accepts any number of sets. The union of no sets
+// is an empty threadsafe set.
+//
+// The returned set is threadsafe if any of the passed sets is threadsafe.
+func UnionAll(sets ...Interface) Interface {
+	if len(sets) == 0 {
+		return newTS()
+	}
+
+	u := newFor(sets...)
+	for _, set := range sets {
+		u.Merge(set)
+	}
+	return u
+}
+
+// IntersectionAll is like Intersection, but accepts any number of sets. The
+// intersection of no sets is defined as an empty threadsafe set.
+//
+// The returned set is threadsafe if any of the passed sets is threadsafe.
+func IntersectionAll(sets ...Interface) Interface {
+	if len(sets) == 0 {
+		return newTS()
+	}
+
+	// only the items of the smallest set can be in all of them
+	smallest := sets[0]
+	for _, set := range sets[1:] {
+		if set.Size() < smallest.Size() {
+			smallest = set
+		}
+	}
+
+	// iterate a snapshot, calling Has on a set from within its Each could
+	// deadlock with a concurrent writer
+	result := newFor(sets...)
+	for _, item := range smallest.List() {
+		inAll := true
+		for _, set := range sets {
+			if inAll = set.Has(item); !inAll {
+				break
+			}
+		}
+		if inAll {
+			result.Add(item)
+		}
+	}
+	return result
+}
+
+// JoinEach calls f once for every item of the union of s and t, reporting
+// whether the item is in s, in t or in both. Both sets are read one after the
+// other, and f is called without holding any of their locks.
+func JoinEach(s, t Interface, f func(item interface{}, inS, inT bool)) {
+	sItems := s.List()
+	inT := make(map[interface{}]bool, t.Size())
+	t.Each(func(item interface{}) bool {
+		inT[item] = false // not visited yet
+		return true
+	})
+
+	for _, item := range sItems {
+		_, ok := inT[item]
+		if ok {
+			inT[item] = true
+		}
+		f(item, true, ok)
+	}
+
+	for item, visited := range inT {
+		if !visited {
+			f(item, false, true)
+		}
+	}
+}
+
+// PairwiseDisjoint reports whether no item is in more than one of the given
+// sets. Instead of comparing every pair of sets, each set is checked against
+// the union of the sets before it, so it runs in time linear to the total
+// number of items. Nil sets are skipped.
+func PairwiseDisjoint(sets ...Interface) bool {
+	seen := make(map[interface{}]struct{})
+	for _, set := range sets {
+		if isNil(set) {
+			continue
+		}
+
+		overlap := false
+		set.Each(func(item interface{}) bool {
+			if _, overlap = seen[item]; overlap {
+				return false
+			}
+			seen[item] = keyExists
+			return true
+		})
+
+		if overlap {
+			return false
+		}
+	}
+	return true
+}
+
+// ItemCount is an item with the number of sets it was found in.
+type ItemCount struct {
+	Item  interface{}
+	Count int
+}
+
+// RankByFrequency returns every distinct item of the given sets with the
+// number of sets that contain it, sorted by descending count. Items with the
+// same count are sorted by their string representation. Nil sets are skipped.
+func RankByFrequency(sets ...Interface) []ItemCount {
+	counts := make(map[interface{}]int)
+	for _, set := range sets {
+		if isNil(set) {
+			continue
+		}
+
+		set.Each(func(item interface{}) bool {
+			counts[item]++
+			return true
+		})
+	}
+
+	ranked := make([]ItemCount, 0, len(counts))
+	for item, n := range counts {
+		ranked = append(ranked, ItemCount{Item: item, Count: n})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if x, y := fmt.Sprint(a.Item), fmt.Sprint(b.Item); x != y {
+			return x < y
+		}
+		return DefaultLess(a.Item, b.Item) // e.g. 1 and "1"
+	})
+	return ranked
+}
+
 // SymmetricDifference returns a new set which s is the difference of items which are in
 // one of either, but not in both.
+//
+// The returned set is threadsafe if either s or t is threadsafe.
 func SymmetricDifference(s Interface, t Interface) Interface {
 	u := Difference(s, t)
 	v := Difference(t, s)
@@ -146,3 +427,51 @@ func IntSlice(s Interface) []int {
 	}
 	return slice
 }
+
+// Float64Slice is a helper function that returns a slice of float64s of s. If
+// the set contains mixed types of items only items of type float64 are
+// returned.
+func Float64Slice(s Interface) []float64 {
+	slice := make([]float64, 0)
+	for _, item := range s.List() {
+		v, ok := item.(float64)
+		if !ok {
+			continue
+		}
+
+		slice = append(slice, v)
+	}
+	return slice
+}
+
+// Collect returns the items of s as a slice of T. It returns an error if any
+// of the items is not of type T; use CollectSkip to ignore them instead.
+func Collect[T any](s Interface) ([]T, error) {
+	list := s.List()
+
+	slice := make([]T, 0, len(list))
+	for _, item := range list {
+		v, ok := item.(T)
+		if !ok {
+			return nil, fmt.Errorf("set: item %v of type %T is not a %s", item, item, reflect.TypeOf((*T)(nil)).Elem())
+		}
+
+		slice = append(slice, v)
+	}
+	return slice, nil
+}
+
+// CollectSkip is like Collect, but items that are not of type T are skipped,
+// like StringSlice() and IntSlice() do.
+func CollectSkip[T any](s Interface) []T {
+	slice := make([]T, 0)
+	for _, item := range s.List() {
+		v, ok := item.(T)
+		if !ok {
+			continue
+		}
+
+		slice = append(slice, v)
+	}
+	return slice
+}
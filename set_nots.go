@@ -151,10 +151,24 @@ func (s *set) Copy() Interface {
 	return u
 }
 
-// String returns a string representation of s
+// ThreadSafe reports whether s is safe for concurrent use. It's always false
+// for the non-threadsafe set.
+func (s *set) ThreadSafe() bool {
+	return false
+}
+
+// String returns a string representation of s. The items are sorted, so
+// equal sets have the same representation.
 func (s *set) String() string {
-	t := make([]string, 0, len(s.List()))
-	for _, item := range s.List() {
+	return format(s.List())
+}
+
+// format returns the string representation of items in sorted order.
+func format(items []interface{}) string {
+	sortItems(items)
+
+	t := make([]string, 0, len(items))
+	for _, item := range items {
 		t = append(t, fmt.Sprintf("%v", item))
 	}
 
@@ -177,7 +191,9 @@ func (s *set) List() []interface{} {
 // with the given t set.
 func (s *set) Merge(t Interface) {
 	t.Each(func(item interface{}) bool {
-		s.m[item] = keyExists
+		if _, ok := s.m[item]; !ok {
+			s.m[item] = keyExists
+		}
 		return true
 	})
 }
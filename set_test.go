@@ -1,7 +1,10 @@
 package set
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -15,7 +18,7 @@ func Test_Union(t *testing.T) {
 
 	u := Union(s, r, x)
 	if settype := reflect.TypeOf(u).String(); settype != "*set.Set" {
-		t.Error("Union should derive its set type from the first passed set, got", settype)
+		t.Error("Union should return a threadsafe set if any passed set is threadsafe, got", settype)
 	}
 	if u.Size() != 7 {
 		t.Error("Union: the merged set doesn't have all items in it.")
@@ -29,8 +32,8 @@ func Test_Union(t *testing.T) {
 	if z.Size() != 5 {
 		t.Error("Union: Union of 2 sets doesn't have the proper number of items.")
 	}
-	if settype := reflect.TypeOf(z).String(); settype != "*set.SetNonTS" {
-		t.Error("Union should derive its set type from the first passed set, got", settype)
+	if settype := reflect.TypeOf(z).String(); settype != "*set.Set" {
+		t.Error("Union should return a threadsafe set if any passed set is threadsafe, got", settype)
 	}
 
 }
@@ -144,6 +147,57 @@ func Test_IntSlice(t *testing.T) {
 	}
 }
 
+func Test_Float64Slice(t *testing.T) {
+	s := New(ThreadSafe)
+	s.Add("san francisco", 1.5, 2.5, 3)
+	u := Float64Slice(s)
+
+	if len(u) != 2 {
+		t.Error("Float64Slice: slice should only have two items")
+	}
+
+	for _, item := range u {
+		r := reflect.TypeOf(item)
+		if r.Kind().String() != "float64" {
+			t.Error("Float64Slice: slice item should be a float64")
+		}
+	}
+}
+
+func Test_Collect(t *testing.T) {
+	s := New(ThreadSafe)
+	s.Add(1, 2, 3)
+
+	ints, err := Collect[int](s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(ints)
+	if !reflect.DeepEqual(ints, []int{1, 2, 3}) {
+		t.Errorf("Collect: got %v, want [1 2 3]", ints)
+	}
+
+	s.Add("4")
+	if _, err := Collect[int](s); err == nil {
+		t.Error("Collect: mixed items should return an error")
+	}
+
+	ints = CollectSkip[int](s)
+	sort.Ints(ints)
+	if !reflect.DeepEqual(ints, []int{1, 2, 3}) {
+		t.Errorf("CollectSkip: got %v, want [1 2 3]", ints)
+	}
+
+	if strs := CollectSkip[string](s); !reflect.DeepEqual(strs, []string{"4"}) {
+		t.Errorf("CollectSkip: got %v, want [4]", strs)
+	}
+
+	_, err = Collect[fmt.Stringer](s)
+	if err == nil || !strings.HasSuffix(err.Error(), "is not a fmt.Stringer") {
+		t.Errorf("Collect: the error should name the interface type, got %v", err)
+	}
+}
+
 func BenchmarkSetEquality(b *testing.B) {
 	s := newTS()
 	u := newTS()
@@ -216,3 +270,383 @@ func BenchmarkIntersection100000(b *testing.B) {
 func BenchmarkIntersection1000000(b *testing.B) {
 	benchmarkIntersection(b, 1000000)
 }
+
+func BenchmarkMerge(b *testing.B) {
+	const n = 1000000
+
+	t := newTS()
+	for i := 0; i < n; i++ {
+		t.Add(i)
+	}
+
+	// s and t overlap by 90%
+	base := newTS()
+	for i := n / 10; i < n+n/10; i++ {
+		base.Add(i)
+	}
+
+	run := func(b *testing.B, merge func(s *Set)) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			s := base.Copy().(*Set)
+			b.StartTimer()
+
+			merge(s)
+		}
+	}
+
+	b.Run("Merge", func(b *testing.B) {
+		run(b, func(s *Set) { s.Merge(t) })
+	})
+
+	// the old loop wrote every item, even the ones s already had
+	b.Run("unconditional", func(b *testing.B) {
+		run(b, func(s *Set) {
+			s.l.Lock()
+			defer s.l.Unlock()
+
+			t.Each(func(item interface{}) bool {
+				s.m[item] = keyExists
+				return true
+			})
+		})
+	})
+}
+
+func Test_Equal(t *testing.T) {
+	var nilTS *Set
+	var nilNonTS *SetNonTS
+
+	s := New(ThreadSafe)
+	s.Add("1", "2")
+	u := New(NonThreadSafe)
+	u.Add("1", "2")
+
+	tests := []struct {
+		a, b Interface
+		want bool
+	}{
+		{nil, nil, true},
+		{nilTS, nil, true},
+		{nilTS, nilNonTS, true},
+		{nil, New(ThreadSafe), true},
+		{New(NonThreadSafe), nilTS, true},
+		{nil, s, false},
+		{s, nilNonTS, false},
+		{s, u, true},
+		{u, New(ThreadSafe), false},
+	}
+
+	for i, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%d): got %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func Test_ThreadSafe(t *testing.T) {
+	if !New(ThreadSafe).ThreadSafe() {
+		t.Error("ThreadSafe: a threadsafe set should report true")
+	}
+
+	if New(NonThreadSafe).ThreadSafe() {
+		t.Error("ThreadSafe: a non-threadsafe set should report false")
+	}
+}
+
+func Test_MixedResultType(t *testing.T) {
+	ts := New(ThreadSafe)
+	ts.Add("1", "2", "3")
+	nts := New(NonThreadSafe)
+	nts.Add("2", "3", "4")
+	nts2 := New(NonThreadSafe)
+	nts2.Add("3", "4", "5")
+
+	mixed := map[string]Interface{
+		"Union":               Union(nts, ts),
+		"Union3":              Union(nts, nts2, ts),
+		"Difference":          Difference(nts, ts),
+		"Intersection":        Intersection(nts, nts2, ts),
+		"SymmetricDifference": SymmetricDifference(nts, ts),
+	}
+	for name, s := range mixed {
+		if _, ok := s.(*Set); !ok || !s.ThreadSafe() {
+			t.Errorf("%s: result of a mixed operation should be threadsafe", name)
+		}
+	}
+
+	if Union(nts, nts2).ThreadSafe() {
+		t.Error("Union: result of non-threadsafe sets should be non-threadsafe")
+	}
+
+	if !Union(nts, ts).IsEqual(Union(ts, nts)) {
+		t.Error("Union: the result should not depend on the order of the sets")
+	}
+}
+
+func benchmarkTopKSet() (*Set, func(interface{}) float64) {
+	s := newTS()
+	for i := 0; i < 100000; i++ {
+		s.Add(i)
+	}
+	return s, func(item interface{}) float64 { return float64(item.(int) % 1000) }
+}
+
+func BenchmarkTopK(b *testing.B) {
+	s, score := benchmarkTopKSet()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.TopK(10, score)
+	}
+}
+
+func BenchmarkTopKFullSort(b *testing.B) {
+	s, score := benchmarkTopKSet()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list := s.List()
+		sort.Slice(list, func(i, j int) bool {
+			return score(list[i]) > score(list[j])
+		})
+		_ = list[:10]
+	}
+}
+
+// benchSet is a package level Interface, so calls on it can't be
+// devirtualized and the variadic slice of Has escapes to the heap, like it does
+// for any Interface the compiler can't see through.
+var benchSet Interface
+
+func BenchmarkHas(b *testing.B) {
+	benchSet = newTS()
+	benchSet.Add("item")
+	item := interface{}("item")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchSet.Has(item)
+	}
+}
+
+func BenchmarkContains(b *testing.B) {
+	s := newTS()
+	s.Add("item")
+	item := interface{}("item")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Contains(item)
+	}
+}
+
+func Test_UnionTagged(t *testing.T) {
+	a := New(ThreadSafe)
+	a.Add("1", "2")
+	b := New(NonThreadSafe)
+	b.Add("2", "3")
+
+	u, origin := UnionTagged(map[string]Interface{"file": a, "env": b})
+	if !u.IsEqual(Union(a, b)) {
+		t.Errorf("UnionTagged: got %s, want %s", u, Union(a, b))
+	}
+
+	want := map[interface{}][]string{
+		"1": {"file"},
+		"2": {"env", "file"},
+		"3": {"env"},
+	}
+	if !reflect.DeepEqual(origin, want) {
+		t.Errorf("UnionTagged: got origin %v, want %v", origin, want)
+	}
+}
+
+func Test_UnionAll(t *testing.T) {
+	a := New(NonThreadSafe)
+	a.Add("1", "2")
+	b := New(NonThreadSafe)
+	b.Add("2", "3")
+	c := New(ThreadSafe)
+	c.Add("3", "4")
+
+	u := UnionAll(a, b, c)
+	if !u.IsEqual(Union(a, b, c)) || !u.ThreadSafe() {
+		t.Errorf("UnionAll: got %s, want a threadsafe %s", u, Union(a, b, c))
+	}
+
+	if u := UnionAll(a, b); u.Size() != 3 || u.ThreadSafe() {
+		t.Errorf("UnionAll: got %s, want a non-threadsafe set of size 3", u)
+	}
+
+	if u := UnionAll(); u == nil || !u.IsEmpty() {
+		t.Error("UnionAll: union of no sets should be empty")
+	}
+
+	if u := UnionAll(a); !u.IsEqual(a) || u == a {
+		t.Error("UnionAll: union of one set should be a copy of it")
+	}
+}
+
+func Test_IntersectionAll(t *testing.T) {
+	a := New(NonThreadSafe)
+	a.Add("1", "2", "3", "4")
+	b := New(NonThreadSafe)
+	b.Add("2", "3", "4")
+	c := New(ThreadSafe)
+	c.Add("3", "4", "5")
+
+	u := IntersectionAll(a, b, c)
+	if !u.IsEqual(Intersection(a, b, c)) || !u.ThreadSafe() {
+		t.Errorf("IntersectionAll: got %s, want a threadsafe %s", u, Intersection(a, b, c))
+	}
+
+	if u := IntersectionAll(); u == nil || !u.IsEmpty() {
+		t.Error("IntersectionAll: intersection of no sets should be empty")
+	}
+
+	if u := IntersectionAll(a); !u.IsEqual(a) {
+		t.Error("IntersectionAll: intersection of one set should be a copy of it")
+	}
+}
+
+func Test_IntersectionAll_concurrent(t *testing.T) {
+	s := newTS()
+	for i := 0; i < 100; i++ {
+		s.Add(i)
+	}
+
+	whileWriting(s, func() {
+		for i := 0; i < 1000; i++ {
+			IntersectionAll(s, s)
+		}
+	})
+}
+
+// whileWriting calls f while another goroutine keeps adding items to s. A
+// writer waiting for the lock blocks new readers, so methods that lock s
+// twice for reading deadlock under it.
+func whileWriting(s *Set, f func()) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				s.Add(i % 100)
+			}
+		}
+	}()
+
+	f()
+	close(stop)
+	<-done
+}
+
+func Test_JoinEach(t *testing.T) {
+	s := New(ThreadSafe)
+	s.Add("1", "2", "3")
+	u := New(NonThreadSafe)
+	u.Add("3", "4")
+
+	type flags struct{ inS, inT bool }
+	got := make(map[interface{}]flags)
+	JoinEach(s, u, func(item interface{}, inS, inT bool) {
+		if _, ok := got[item]; ok {
+			t.Errorf("JoinEach: %v should be visited once", item)
+		}
+		got[item] = flags{inS, inT}
+	})
+
+	want := map[interface{}]flags{
+		"1": {true, false},
+		"2": {true, false},
+		"3": {true, true},
+		"4": {false, true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JoinEach: got %v, want %v", got, want)
+	}
+}
+
+func Test_NewOptions(t *testing.T) {
+	tests := []struct {
+		opts       []Option
+		threadSafe bool
+		interned   bool
+	}{
+		{nil, true, false},
+		{[]Option{ThreadSafe}, true, false},
+		{[]Option{NonThreadSafe}, false, false},
+		{[]Option{WithThreadSafe(false), WithCapacity(1000)}, false, false},
+		{[]Option{NonThreadSafe, WithThreadSafe(true)}, true, false},
+		{[]Option{WithCapacity(1000), WithInterned()}, true, true},
+		{[]Option{WithInterned(), NonThreadSafe}, false, false},
+	}
+
+	for i, tt := range tests {
+		s := New(tt.opts...)
+		if s.ThreadSafe() != tt.threadSafe {
+			t.Errorf("New(%d): threadsafe should be %v", i, tt.threadSafe)
+		}
+
+		if ts, ok := s.(*Set); ok && ts.interned != tt.interned {
+			t.Errorf("New(%d): interned should be %v", i, tt.interned)
+		}
+
+		s.Add("1", "2")
+		if s.Size() != 2 {
+			t.Errorf("New(%d): set should be usable", i)
+		}
+	}
+}
+
+func Test_PairwiseDisjoint(t *testing.T) {
+	a := New(ThreadSafe)
+	a.Add("1", "2")
+	b := New(NonThreadSafe)
+	b.Add("3", "4")
+	c := New(ThreadSafe)
+	c.Add("5", "6")
+	var nilSet *Set
+
+	if !PairwiseDisjoint(a, b, nil, nilSet, c) {
+		t.Error("PairwiseDisjoint: sets have no items in common")
+	}
+
+	c.Add("2")
+	if PairwiseDisjoint(a, b, c) {
+		t.Error("PairwiseDisjoint: a and c have an item in common")
+	}
+
+	if !PairwiseDisjoint() || !PairwiseDisjoint(a) {
+		t.Error("PairwiseDisjoint: less than two sets are always disjoint")
+	}
+}
+
+func Test_RankByFrequency(t *testing.T) {
+	a := New(ThreadSafe)
+	a.Add("go", "rust", "c")
+	b := New(NonThreadSafe)
+	b.Add("go", "c")
+	c := New(ThreadSafe)
+	c.Add("go", "zig")
+	var nilSet *Set
+
+	got := RankByFrequency(a, nil, b, nilSet, c)
+	want := []ItemCount{
+		{"go", 3},
+		{"c", 2},
+		{"rust", 1},
+		{"zig", 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RankByFrequency: got %v, want %v", got, want)
+	}
+}
@@ -1,11 +1,31 @@
 package set
 
-import "sync"
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
 
 // Set defines a thread safe set data structure.
 type Set struct {
 	set
 	l sync.RWMutex // we name it because we don't want to expose it
+
+	interned bool // strings are interned, see NewInterned()
+
+	onAdd    []func(item interface{}) // see OnAdd()
+	onRemove []func(item interface{}) // see OnRemove()
 }
 
 // New creates and initialize a new Set. It's accept a variable number of
@@ -21,6 +41,14 @@ func newTS() *Set {
 	return s
 }
 
+// empty returns a new empty Set with the same mode as s, e.g. interned. Sets
+// derived from s must be created with it, so they behave the same as s.
+func (s *Set) empty() *Set {
+	u := newTS()
+	u.interned = s.interned
+	return u
+}
+
 // Add includes the specified items (one or more) to the set. The underlying
 // Set s is modified. If passed nothing it silently returns.
 func (s *Set) Add(items ...interface{}) {
@@ -28,11 +56,11 @@ func (s *Set) Add(items ...interface{}) {
 		return
 	}
 
-	s.l.Lock()
-	defer s.l.Unlock()
+	c := s.lock()
+	defer c.unlock()
 
 	for _, item := range items {
-		s.m[item] = keyExists
+		c.add(item)
 	}
 }
 
@@ -43,27 +71,144 @@ func (s *Set) Remove(items ...interface{}) {
 		return
 	}
 
+	c := s.lock()
+	defer c.unlock()
+
+	for _, item := range items {
+		c.remove(item)
+	}
+}
+
+// OnAdd registers fn to be called for every item that is added to s. It's
+// only called for items that were not in s before. See OnRemove() for details.
+func (s *Set) OnAdd(fn func(item interface{})) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.onAdd = append(s.onAdd, fn)
+}
+
+// OnRemove registers fn to be called for every item that is removed from s.
+// It's only called for items that were in s.
+//
+// Callbacks are called by every method that modifies s, after the lock is
+// released, so they can call methods of s. Removals are reported before
+// additions, and the callbacks are called in the order they were registered.
+// Sets derived from s, like Copy(), don't inherit them.
+func (s *Set) OnRemove(fn func(item interface{})) {
 	s.l.Lock()
 	defer s.l.Unlock()
 
+	s.onRemove = append(s.onRemove, fn)
+}
+
+// changes records the items added to and removed from a Set while holding
+// its write lock, so the OnAdd() and OnRemove() callbacks can be called once
+// it's released. Every method that modifies a Set must do it through changes.
+type changes struct {
+	s              *Set
+	added, removed []interface{}
+}
+
+// lock acquires the write lock of s and returns the changes to modify s
+// with. Call unlock to release the lock and call the callbacks.
+func (s *Set) lock() *changes {
+	s.l.Lock()
+	return &changes{s: s}
+}
+
+// add adds item to the set and reports whether it was not in the set before.
+func (c *changes) add(item interface{}) bool {
+	// a lookup is cheaper than an assignment for items that already exist
+	if _, ok := c.s.m[item]; ok {
+		return false
+	}
+
+	item = c.s.normalize(item)
+	c.s.m[item] = keyExists
+	if len(c.s.onAdd) > 0 {
+		c.added = append(c.added, item)
+	}
+	return true
+}
+
+// remove deletes item from the set and reports whether it was in the set.
+func (c *changes) remove(item interface{}) bool {
+	if _, ok := c.s.m[item]; !ok {
+		return false
+	}
+
+	delete(c.s.m, item)
+	if len(c.s.onRemove) > 0 {
+		c.removed = append(c.removed, item)
+	}
+	return true
+}
+
+// reset replaces the items of the set with the keys of m.
+func (c *changes) reset(m map[interface{}]struct{}) {
+	s := c.s
+	if len(s.onRemove) > 0 {
+		for item := range s.m {
+			if _, ok := m[item]; !ok {
+				c.removed = append(c.removed, item)
+			}
+		}
+	}
+	if len(s.onAdd) > 0 {
+		for item := range m {
+			if _, ok := s.m[item]; !ok {
+				c.added = append(c.added, item)
+			}
+		}
+	}
+	s.m = m
+}
+
+// unlock releases the write lock of the set and calls the callbacks for the
+// recorded changes.
+func (c *changes) unlock() {
+	onAdd, onRemove := c.s.onAdd, c.s.onRemove
+	c.s.l.Unlock()
+
+	fire(onRemove, c.removed)
+	fire(onAdd, c.added)
+}
+
+// fire calls every callback for every item.
+func fire(callbacks []func(item interface{}), items []interface{}) {
 	for _, item := range items {
-		delete(s.m, item)
+		for _, fn := range callbacks {
+			fn(item)
+		}
 	}
 }
 
 // Pop  deletes and return an item from the set. The underlying Set s is
-// modified. If set is empty, nil is returned.
+// modified. If set is empty or nil, nil is returned. Popping the last item
+// leaves an empty set that can still be used.
 func (s *Set) Pop() interface{} {
-	s.l.RLock()
+	if s == nil {
+		return nil
+	}
+
+	item, _ := s.pop()
+	return item
+}
+
+// pop is like Pop, but ok reports whether an item was popped, so a nil item
+// can be told apart from an empty set.
+func (s *Set) pop() (item interface{}, ok bool) {
+	// the write lock is held for the whole lookup, otherwise two goroutines
+	// could pop the same item
+	c := s.lock()
+	defer c.unlock()
+
 	for item := range s.m {
-		s.l.RUnlock()
-		s.l.Lock()
-		delete(s.m, item)
-		s.l.Unlock()
-		return item
+		c.remove(item)
+		return item, true
 	}
-	s.l.RUnlock()
-	return nil
+	return nil, false
 }
 
 // Has looks for the existence of items passed. It returns false if nothing is
@@ -86,6 +231,18 @@ func (s *Set) Has(items ...interface{}) bool {
 	return has
 }
 
+// Contains reports whether item exists in the set. It's like Has, but for a
+// single item, so there is no variadic slice to build and loop over. Calling
+// Has through an Interface allocates that slice on every call, Contains
+// doesn't allocate.
+func (s *Set) Contains(item interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	_, ok := s.m[item]
+	return ok
+}
+
 // Size returns the number of items in a set.
 func (s *Set) Size() int {
 	s.l.RLock()
@@ -95,12 +252,17 @@ func (s *Set) Size() int {
 	return l
 }
 
+// IsEmpty reports whether the Set is empty.
+func (s *Set) IsEmpty() bool {
+	return s.Size() == 0
+}
+
 // Clear removes all items from the set.
 func (s *Set) Clear() {
-	s.l.Lock()
-	defer s.l.Unlock()
+	c := s.lock()
+	defer c.unlock()
 
-	s.m = make(map[interface{}]struct{})
+	c.reset(make(map[interface{}]struct{}))
 }
 
 // IsEqual test whether s and t are the same in size and have the same items.
@@ -128,6 +290,67 @@ func (s *Set) IsEqual(t Interface) bool {
 	return equal
 }
 
+// SetLike is implemented by any set-like type, including Interface.
+type SetLike interface {
+	List() []interface{}
+	Size() int
+}
+
+// IsEqualI is like IsEqual, but t can be any set-like value, such as a
+// wrapper around a map.
+func (s *Set) IsEqualI(t SetLike) bool {
+	list := t.List() // t may be s, read it before locking
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.m) != len(list) {
+		return false
+	}
+
+	for _, item := range list {
+		if _, ok := s.m[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEqualExcept is like IsEqual, but ignores the items of ignore in both s
+// and t. Either of them may be s itself.
+func (s *Set) IsEqualExcept(t, ignore Interface) bool {
+	// t and ignore may be s, read them before locking
+	ignored := make(map[interface{}]struct{}, ignore.Size())
+	for _, item := range ignore.List() {
+		ignored[item] = keyExists
+	}
+
+	remaining := make(map[interface{}]struct{}, t.Size())
+	for _, item := range t.List() {
+		if _, ok := ignored[item]; !ok {
+			remaining[item] = keyExists
+		}
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	n := 0
+	for item := range s.m {
+		if _, ok := ignored[item]; ok {
+			continue
+		}
+		if _, ok := remaining[item]; !ok {
+			return false
+		}
+		n++
+	}
+
+	// all remaining items of s are in t, so t has no other items if the
+	// numbers are the same
+	return n == len(remaining)
+}
+
 // IsSubset tests whether t is a subset of s.
 func (s *Set) IsSubset(t Interface) (subset bool) {
 	s.l.RLock()
@@ -145,8 +368,14 @@ func (s *Set) IsSubset(t Interface) (subset bool) {
 
 // Each traverses the items in the Set, calling the provided function for each
 // set member. Traversal will continue until all items in the Set have been
-// visited, or if the closure returns false.
+// visited, or if the closure returns false. It holds the read lock, so unlike
+// List() it doesn't copy the items, but f must not modify s. A nil Set has no
+// items, so f is never called.
 func (s *Set) Each(f func(item interface{}) bool) {
+	if s == nil {
+		return
+	}
+
 	s.l.RLock()
 	defer s.l.RUnlock()
 
@@ -172,23 +401,1544 @@ func (s *Set) List() []interface{} {
 	return list
 }
 
-// Copy returns a new Set with a copy of s.
+// SortedList returns a slice of all items, sorted in the order defined by
+// DefaultLess.
+func (s *Set) SortedList() []interface{} {
+	list := s.List()
+	sortItems(list)
+	return list
+}
+
+// String returns a string representation of s. The items are sorted, so
+// equal sets have the same representation.
+func (s *Set) String() string {
+	return format(s.List())
+}
+
+// Copy returns a new Set with a copy of s. It's the same as Clone() but
+// returns an Interface.
 func (s *Set) Copy() Interface {
-	u := newTS()
+	return s.Clone()
+}
+
+// Clone returns a new Set with a copy of s. The underlying map is copied
+// directly, pre-sized to the size of s.
+func (s *Set) Clone() *Set {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	u := s.empty()
+	u.m = make(map[interface{}]struct{}, len(s.m))
 	for item := range s.m {
-		u.Add(item)
+		u.m[item] = keyExists
 	}
 	return u
 }
 
+// ThreadSafe reports whether s is safe for concurrent use. It's always true
+// for the threadsafe set.
+func (s *Set) ThreadSafe() bool {
+	return true
+}
+
 // Merge is like Union, however it modifies the current set it's applied on
 // with the given t set.
 func (s *Set) Merge(t Interface) {
-	s.l.Lock()
-	defer s.l.Unlock()
+	c := s.lock()
+	defer c.unlock()
+
+	t.Each(func(item interface{}) bool {
+		c.add(item)
+		return true
+	})
+}
+
+// Separate removes the set items containing in t from set s. Please aware that
+// it's not the opposite of Merge.
+func (s *Set) Separate(t Interface) {
+	s.Remove(t.List()...)
+}
+
+// IntersectionCount returns the number of items that exist in both s and t.
+// Unlike Intersection() it doesn't allocate a new set; only the items of the
+// smaller set are copied and looked up in the larger one.
+func (s *Set) IntersectionCount(t Interface) int {
+	if u, ok := t.(*Set); ok && u == s {
+		return s.Size()
+	}
+
+	if s.Size() > t.Size() {
+		items := t.List() // read t before locking, it may be a view of s
+
+		s.l.RLock()
+		defer s.l.RUnlock()
+
+		return s.countIn(items)
+	}
+
+	// the lock of s is not held while t is queried
+	has := func(item interface{}) bool { return t.Has(item) }
+	if u, ok := t.(*Set); ok {
+		has = u.Contains // avoids the variadic slice of Has
+	}
+
+	count := 0
+	for _, item := range s.List() {
+		if has(item) {
+			count++
+		}
+	}
+	return count
+}
+
+// countIn returns the number of items that exist in s. It expects the caller
+// to hold the lock of s.
+func (s *Set) countIn(items []interface{}) int {
+	count := 0
+	for _, item := range items {
+		if _, ok := s.m[item]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+// AddSafe is like Add, but instead of panicking on items that can't be used as
+// map keys it returns an error. Allowed items are the comparable ones: bools,
+// numbers, strings, pointers, channels, interfaces and arrays or structs made
+// of those. Slices, maps and funcs are rejected. If any of the items is not
+// comparable the set is left untouched.
+func (s *Set) AddSafe(items ...interface{}) error {
+	for _, item := range items {
+		if !isComparable(item) {
+			return fmt.Errorf("set: item of type %T is not comparable", item)
+		}
+	}
+
+	s.Add(items...)
+	return nil
+}
+
+// isComparable reports whether item can be stored as a map key. Comparing an
+// interface value holding an uncomparable type panics, which also catches
+// structs or arrays that embed slices, maps or funcs behind an interface.
+func isComparable(item interface{}) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	_ = item == item
+	return true
+}
+
+// DeepCopy returns a new Set with the items of s passed through clone. It's
+// useful when items are pointers and the copy shouldn't share the values they
+// point to. If clone is nil it falls back to a shallow Copy().
+func (s *Set) DeepCopy(clone func(item interface{}) interface{}) *Set {
+	if clone == nil {
+		return s.Copy().(*Set)
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	u := s.empty()
+	for item := range s.m {
+		u.m[u.normalize(clone(item))] = keyExists
+	}
+	return u
+}
+
+// Page returns at most limit items of s starting at offset, in the order
+// defined by DefaultLess. Because the order is deterministic, successive pages
+// of an unchanged set never overlap. An out of range offset or a non-positive
+// limit returns an empty slice.
+func (s *Set) Page(offset, limit int) []interface{} {
+	list := s.List()
+	if offset < 0 || offset >= len(list) || limit <= 0 {
+		return []interface{}{}
+	}
+
+	sortItems(list)
+
+	end := offset + limit
+	if end > len(list) || end < 0 {
+		end = len(list)
+	}
+	return list[offset:end]
+}
+
+// TopK returns the k items of s with the highest scores, ordered from the
+// highest to the lowest score. Items with equal scores are ordered like the
+// sorted methods, e.g. Page(), so the result is deterministic. k is clamped to
+// the size of s. It keeps a bounded heap, so it runs in O(n log k).
+func (s *Set) TopK(k int, score func(item interface{}) float64) []interface{} {
+	list := s.List()
+	if k > len(list) {
+		k = len(list)
+	}
+	if k <= 0 {
+		return []interface{}{}
+	}
+
+	h := make(scoredHeap, 0, k)
+	for _, item := range list {
+		x := scoredItem{item: item, score: score(item)}
+		if len(h) < k {
+			heap.Push(&h, x)
+			continue
+		}
+
+		if h[0].worse(x) {
+			h[0] = x
+			heap.Fix(&h, 0)
+		}
+	}
+
+	top := make([]interface{}, len(h))
+	for i := len(h) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(&h).(scoredItem).item
+	}
+	return top
+}
+
+// EqualsMapKeys reports whether s contains exactly the keys of m, which can be
+// a map of any type. It returns false if m is not a map.
+func (s *Set) EqualsMapKeys(m interface{}) bool {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return false
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if v.Len() != len(s.m) {
+		return false
+	}
+
+	it := v.MapRange()
+	for it.Next() {
+		if _, ok := s.m[it.Key().Interface()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// EachIndexed is like Each, but visits the items in sorted order and passes
+// the index of each item in that order. The items are taken from a snapshot
+// of s, so f may modify s. Traversal stops if the closure returns false.
+func (s *Set) EachIndexed(f func(i int, item interface{}) bool) {
+	for i, item := range s.SortedList() {
+		if !f(i, item) {
+			break
+		}
+	}
+}
+
+// SymmetricDifferenceCount returns the number of items that are in either s
+// or t, but not in both. It's computed from the sizes and the intersection
+// count, without allocating the symmetric difference.
+func (s *Set) SymmetricDifferenceCount(t Interface) int {
+	return s.Size() + t.Size() - 2*s.IntersectionCount(t)
+}
 
+// RemoveFunc deletes all items for which pred returns true and returns the
+// number of deleted items. It's done in a single pass while holding the lock,
+// so pred must not call any methods of s.
+func (s *Set) RemoveFunc(pred func(item interface{}) bool) int {
+	c := s.lock()
+	defer c.unlock()
+
+	n := 0
+	for item := range s.m {
+		// deleting while ranging over a map is safe
+		if pred(item) {
+			c.remove(item)
+			n++
+		}
+	}
+	return n
+}
+
+// RetainFunc deletes all items for which pred returns false and returns the
+// number of remaining items. It's the complement of RemoveFunc and, like it,
+// pred is called while holding the lock, so it must not call any methods of s.
+func (s *Set) RetainFunc(pred func(item interface{}) bool) int {
+	c := s.lock()
+	defer c.unlock()
+
+	for item := range s.m {
+		if !pred(item) {
+			c.remove(item)
+		}
+	}
+	return len(s.m)
+}
+
+// DifferenceSeq returns an iterator over the items that are in s but not in t,
+// without allocating a new set. The items of s are taken from a snapshot when
+// the iteration starts, and each of them is looked up in t as it's yielded.
+func (s *Set) DifferenceSeq(t Interface) iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		for _, item := range s.List() {
+			if t.Has(item) {
+				continue
+			}
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// MergeCapped is like Merge, but stops adding items once s has max items. It
+// returns the number of added items and whether some items of t were left
+// out. Items of t are added in the order defined by DefaultLess, so the same
+// items are left out for the same sets.
+func (s *Set) MergeCapped(t Interface, max int) (added int, truncated bool) {
+	list := t.List()
+	sortItems(list)
+
+	c := s.lock()
+	defer c.unlock()
+
+	for _, item := range list {
+		if _, ok := s.m[item]; ok {
+			continue
+		}
+
+		if len(s.m) >= max {
+			return added, true
+		}
+
+		c.add(item)
+		added++
+	}
+	return added, false
+}
+
+// StringN is like String, but prints at most max items followed by the number
+// of the items that are left out, e.g. "[1, 2, ... (+3 more)]". Prefer it over
+// String for logging sets that can be large.
+func (s *Set) StringN(max int) string {
+	list := s.SortedList()
+	if max < 0 {
+		max = 0
+	}
+	if len(list) <= max {
+		return format(list)
+	}
+
+	t := make([]string, 0, max+1)
+	for _, item := range list[:max] {
+		t = append(t, fmt.Sprintf("%v", item))
+	}
+	t = append(t, fmt.Sprintf("... (+%d more)", len(list)-max))
+
+	return fmt.Sprintf("[%s]", strings.Join(t, ", "))
+}
+
+// Swap replaces the items of s with a copy of the items of t and returns a new
+// set with the previous items of s. The items are replaced at once, so other
+// goroutines see either the previous or the new items, but never a mix.
+func (s *Set) Swap(t Interface) *Set {
+	m := make(map[interface{}]struct{}, t.Size())
 	t.Each(func(item interface{}) bool {
-		s.m[item] = keyExists
+		m[s.normalize(item)] = keyExists
 		return true
 	})
+
+	c := s.lock()
+	old := s.m
+	c.reset(m)
+	c.unlock()
+
+	u := s.empty()
+	u.m = old
+	return u
+}
+
+// EachRemove calls f for each item of a snapshot of s and deletes the items
+// for which f returns true. Unlike RemoveFunc, f is called without holding the
+// lock, so it may call methods of s. It returns the number of deleted items,
+// which doesn't include items that were removed concurrently in the meantime.
+func (s *Set) EachRemove(f func(item interface{}) (remove bool)) int {
+	var remove []interface{}
+	for _, item := range s.List() {
+		if f(item) {
+			remove = append(remove, item)
+		}
+	}
+
+	if len(remove) == 0 {
+		return 0
+	}
+
+	c := s.lock()
+	defer c.unlock()
+
+	n := 0
+	for _, item := range remove {
+		if c.remove(item) {
+			n++
+		}
+	}
+	return n
+}
+
+// SubtractReport is like Separate, but returns the items that were actually
+// deleted from s, i.e. the items of t that were in s.
+func (s *Set) SubtractReport(t Interface) []interface{} {
+	items := t.List()
+
+	c := s.lock()
+	defer c.unlock()
+
+	removed := make([]interface{}, 0)
+	for _, item := range items {
+		if c.remove(item) {
+			removed = append(removed, item)
+		}
+	}
+	return removed
+}
+
+// MaxCombinations is the maximum number of combinations returned by
+// Combinations. Use EachCombination to iterate over more of them.
+const MaxCombinations = 1 << 20
+
+// Combinations returns all subsets of s with k items, as slices. The items of
+// each combination and the combinations themselves are in lexicographic order
+// as defined by DefaultLess. It returns nil if there would be more than
+// MaxCombinations combinations.
+func (s *Set) Combinations(k int) [][]interface{} {
+	list := s.SortedList()
+	if binomial(len(list), k) > MaxCombinations {
+		return nil
+	}
+
+	combs := make([][]interface{}, 0)
+	eachCombination(list, k, func(comb []interface{}) bool {
+		combs = append(combs, append([]interface{}(nil), comb...))
+		return true
+	})
+	return combs
+}
+
+// EachCombination is like Combinations, but calls f for each combination
+// instead of returning them, so there is no limit on their number. The slice
+// passed to f is reused between calls and must be copied to be retained.
+// Iteration stops if f returns false.
+func (s *Set) EachCombination(k int, f func(comb []interface{}) bool) {
+	eachCombination(s.SortedList(), k, f)
+}
+
+// eachCombination calls f for each k item combination of list in
+// lexicographic order.
+func eachCombination(list []interface{}, k int, f func(comb []interface{}) bool) {
+	n := len(list)
+	if k < 0 || k > n {
+		return
+	}
+
+	idx := make([]int, k)
+	for i := range idx {
+		idx[i] = i
+	}
+
+	comb := make([]interface{}, k)
+	for {
+		for i, j := range idx {
+			comb[i] = list[j]
+		}
+		if !f(comb) {
+			return
+		}
+
+		// find the rightmost index that can still be incremented
+		i := k - 1
+		for i >= 0 && idx[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+
+		idx[i]++
+		for j := i + 1; j < k; j++ {
+			idx[j] = idx[j-1] + 1
+		}
+	}
+}
+
+// binomial returns n choose k, or a value above MaxCombinations if it's
+// larger than that.
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	if k > n-k {
+		k = n - k
+	}
+
+	c := 1
+	for i := 1; i <= k; i++ {
+		c = c * (n - k + i) / i
+		if c > MaxCombinations {
+			return MaxCombinations + 1
+		}
+	}
+	return c
+}
+
+// AddReportDuplicates is like Add, but returns the items that were already in
+// the set, either before the call or because they were passed more than once.
+// An item passed n times that wasn't in the set is reported n-1 times.
+func (s *Set) AddReportDuplicates(items ...interface{}) (duplicates []interface{}) {
+	c := s.lock()
+	defer c.unlock()
+
+	for _, item := range items {
+		if !c.add(item) {
+			duplicates = append(duplicates, item)
+		}
+	}
+	return duplicates
+}
+
+// SumInt returns the sum of the items of type int. Like IntSlice() other items
+// are ignored. It returns false if there are no int items.
+func (s *Set) SumInt() (int, bool) {
+	ints := IntSlice(s)
+
+	sum := 0
+	for _, v := range ints {
+		sum += v
+	}
+	return sum, len(ints) > 0
+}
+
+// SumFloat64 returns the sum of the items of type float64. Like Float64Slice()
+// other items are ignored. It returns false if there are no float64 items.
+func (s *Set) SumFloat64() (float64, bool) {
+	floats := Float64Slice(s)
+
+	sum := 0.0
+	for _, v := range floats {
+		sum += v
+	}
+	return sum, len(floats) > 0
+}
+
+// MeanFloat64 returns the mean of the items of type float64. Like
+// Float64Slice() other items are ignored. It returns false if there are no
+// float64 items.
+func (s *Set) MeanFloat64() (float64, bool) {
+	floats := Float64Slice(s)
+	if len(floats) == 0 {
+		return 0, false
+	}
+
+	sum := 0.0
+	for _, v := range floats {
+		sum += v
+	}
+	return sum / float64(len(floats)), true
+}
+
+// IsSupersetOfSlice tests whether s contains every item of items. Duplicate
+// items are treated as one, and it's true for a nil or empty slice.
+func (s *Set) IsSupersetOfSlice(items []interface{}) bool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for _, item := range items {
+		if _, ok := s.m[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSubsetOfSlice tests whether every item of s is in items. Duplicate items
+// are treated as one, and for a nil or empty slice it's only true if s is
+// empty.
+func (s *Set) IsSubsetOfSlice(items []interface{}) bool {
+	u := make(map[interface{}]struct{}, len(items))
+	for _, item := range items {
+		u[item] = keyExists
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.m) > len(u) {
+		return false
+	}
+
+	for item := range s.m {
+		if _, ok := u[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitBySize partitions s into new sets of at most maxPerChunk items each.
+// Items are assigned in the order defined by DefaultLess, so all chunks but
+// the last one are full and the same set is always split the same way. It
+// returns nil if maxPerChunk is not positive.
+func (s *Set) SplitBySize(maxPerChunk int) []*Set {
+	if maxPerChunk <= 0 {
+		return nil
+	}
+
+	list := s.SortedList()
+	chunks := make([]*Set, 0, (len(list)+maxPerChunk-1)/maxPerChunk)
+	for len(list) > 0 {
+		n := maxPerChunk
+		if n > len(list) {
+			n = len(list)
+		}
+
+		u := s.empty()
+		u.Add(list[:n]...)
+		chunks = append(chunks, u)
+		list = list[n:]
+	}
+	return chunks
+}
+
+// IntersectsAny reports whether s has at least one item in common with any of
+// the given sets. It stops at the first common item found. Nil sets are
+// skipped.
+func (s *Set) IntersectsAny(others ...Interface) bool {
+	// s may be one of others, so it's read into its own map first and isn't
+	// locked while the others are queried
+	m := s.toMap()
+
+	for _, t := range others {
+		if isNil(t) {
+			continue
+		}
+
+		found := false
+		if len(m) <= t.Size() {
+			for item := range m {
+				if t.Has(item) {
+					found = true
+					break
+				}
+			}
+		} else {
+			t.Each(func(item interface{}) bool {
+				_, found = m[item]
+				return !found
+			})
+		}
+
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// toMap returns a copy of the underlying map of s.
+func (s *Set) toMap() map[interface{}]struct{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	m := make(map[interface{}]struct{}, len(s.m))
+	for item := range s.m {
+		m[item] = keyExists
+	}
+	return m
+}
+
+// Snapshot returns the size and the items of s, read while holding the lock
+// once, so size is always equal to len(items).
+func (s *Set) Snapshot() (size int, items []interface{}) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	items = make([]interface{}, 0, len(s.m))
+	for item := range s.m {
+		items = append(items, item)
+	}
+	return len(s.m), items
+}
+
+// RemoveIntersection deletes the items of s that are also in t and returns s.
+// It's the in-place counterpart of Difference.
+func (s *Set) RemoveIntersection(t Interface) *Set {
+	items := t.List()
+
+	c := s.lock()
+	defer c.unlock()
+
+	for _, item := range items {
+		c.remove(item)
+	}
+	return s
+}
+
+// RetainAll deletes the items of s that are not in t and returns s. It's the
+// in-place counterpart of Intersection.
+func (s *Set) RetainAll(t Interface) *Set {
+	keep := make(map[interface{}]struct{}, t.Size())
+	t.Each(func(item interface{}) bool {
+		keep[item] = keyExists
+		return true
+	})
+
+	c := s.lock()
+	defer c.unlock()
+
+	for item := range s.m {
+		if _, ok := keep[item]; !ok {
+			c.remove(item)
+		}
+	}
+	return s
+}
+
+// ChangesSince returns the items that were added to and removed from old to
+// get s, each sorted in the order defined by DefaultLess. Applying them to old
+// in that order gives a set equal to s.
+func (s *Set) ChangesSince(old Interface) (added, removed []interface{}) {
+	cur := s.Clone()
+	prev := old.List()
+
+	added, removed = make([]interface{}, 0), make([]interface{}, 0)
+	for _, item := range prev {
+		if _, ok := cur.m[item]; ok {
+			delete(cur.m, item)
+		} else {
+			removed = append(removed, item)
+		}
+	}
+	for item := range cur.m {
+		added = append(added, item)
+	}
+
+	sortItems(added)
+	sortItems(removed)
+	return added, removed
+}
+
+// UnionNormalized returns a new set with the items of s and t, where numbers
+// are converted to their canonical form by NormalizeNumber. Unlike Union,
+// numbers of different types with the same value, e.g. 1 and int64(1), are a
+// single item in the result.
+func (s *Set) UnionNormalized(t Interface) *Set {
+	u := s.empty()
+	for _, item := range s.List() {
+		u.m[u.normalize(NormalizeNumber(item))] = keyExists
+	}
+	for _, item := range t.List() {
+		u.m[u.normalize(NormalizeNumber(item))] = keyExists
+	}
+	return u
+}
+
+// FromMapKeys returns a new Set with the keys of m, which can be a map of any
+// type. It returns an error if m is not a map.
+func FromMapKeys(m interface{}) (*Set, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil, fmt.Errorf("set: %T is not a map", m)
+	}
+
+	s := newTS()
+	it := v.MapRange()
+	for it.Next() {
+		s.m[it.Key().Interface()] = keyExists
+	}
+	return s, nil
+}
+
+// FromMapValues returns a new Set with the values of m, which can be a map of
+// any type. It returns an error if m is not a map or if its values are not
+// comparable.
+func FromMapValues(m interface{}) (*Set, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		return nil, fmt.Errorf("set: %T is not a map", m)
+	}
+
+	items := make([]interface{}, 0, v.Len())
+	it := v.MapRange()
+	for it.Next() {
+		items = append(items, it.Value().Interface())
+	}
+
+	s := newTS()
+	if err := s.AddSafe(items...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// KindCounts returns the number of items of each kind. Nil items are counted
+// as reflect.Invalid.
+func (s *Set) KindCounts() map[reflect.Kind]int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	counts := make(map[reflect.Kind]int)
+	for item := range s.m {
+		counts[reflect.ValueOf(item).Kind()]++
+	}
+	return counts
+}
+
+// KindEntropy returns the Shannon entropy, in bits, of the kinds of the items
+// in s. It's 0 for an empty set or a set of a single kind, and grows the more
+// evenly the items are spread across more kinds.
+func (s *Set) KindEntropy() float64 {
+	return entropy(s.KindCounts())
+}
+
+// entropy returns the Shannon entropy, in bits, of the distribution given by
+// counts.
+func entropy(counts map[reflect.Kind]int) float64 {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	h := 0.0
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// MergeAll is like Merge, but merges all the given sets while holding the
+// lock of s once, so other goroutines see either none or all of them merged.
+// Nil sets are skipped. It returns s.
+func (s *Set) MergeAll(others ...Interface) *Set {
+	lists := make([][]interface{}, 0, len(others))
+	for _, t := range others {
+		if isNil(t) {
+			continue
+		}
+		lists = append(lists, t.List())
+	}
+
+	c := s.lock()
+	defer c.unlock()
+
+	for _, list := range lists {
+		for _, item := range list {
+			c.add(item)
+		}
+	}
+	return s
+}
+
+// SubsetSeeded returns a new set with n items of s picked at random. The pick
+// only depends on seed and the items of s, so the same seed gives the same
+// subset for equal sets on any machine. n is clamped to the size of s.
+func (s *Set) SubsetSeeded(n int, seed int64) *Set {
+	list := s.SortedList()
+	if n > len(list) {
+		n = len(list)
+	}
+
+	u := s.empty()
+	r := rand.New(rand.NewSource(seed))
+
+	// partial Fisher-Yates shuffle of the first n items
+	for i := 0; i < n; i++ {
+		j := i + r.Intn(len(list)-i)
+		list[i], list[j] = list[j], list[i]
+		u.m[list[i]] = keyExists
+	}
+	return u
+}
+
+// Drain pops items from s and calls f with each of them until s is empty. f
+// may add new items to s, which are drained as well, e.g. to process the
+// frontier of a graph traversal.
+func (s *Set) Drain(f func(item interface{})) {
+	for item, ok := s.pop(); ok; item, ok = s.pop() {
+		f(item)
+	}
+}
+
+// AppendStrings appends the items of type string to dst and returns the
+// extended slice, like StringSlice() but without allocating a new slice. The
+// items are appended in no particular order; see AppendSortedStrings.
+func (s *Set) AppendStrings(dst []string) []string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for item := range s.m {
+		if v, ok := item.(string); ok {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// AppendSortedStrings is like AppendStrings, but the appended items are
+// sorted.
+func (s *Set) AppendSortedStrings(dst []string) []string {
+	n := len(dst)
+	dst = s.AppendStrings(dst)
+	sort.Strings(dst[n:])
+	return dst
+}
+
+// AppendInts appends the items of type int to dst and returns the extended
+// slice, like IntSlice() but without allocating a new slice. The items are
+// appended in no particular order; see AppendSortedInts.
+func (s *Set) AppendInts(dst []int) []int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for item := range s.m {
+		if v, ok := item.(int); ok {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// AppendSortedInts is like AppendInts, but the appended items are sorted.
+func (s *Set) AppendSortedInts(dst []int) []int {
+	n := len(dst)
+	dst = s.AppendInts(dst)
+	sort.Ints(dst[n:])
+	return dst
+}
+
+// IsContiguousIntRange reports whether s is made of ints that form the range
+// [min, max] without gaps. min and max are returned for any non-empty set of
+// ints, even if it has gaps. ok is false for empty sets and sets with items
+// that are not ints.
+func (s *Set) IsContiguousIntRange() (min, max int, ok bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.m) == 0 {
+		return 0, 0, false
+	}
+
+	first := true
+	for item := range s.m {
+		v, isInt := item.(int)
+		if !isInt {
+			return 0, 0, false
+		}
+
+		if first || v < min {
+			min = v
+		}
+		if first || v > max {
+			max = v
+		}
+		first = false
+	}
+
+	// the items are distinct, so there are no gaps if there are as many items
+	// as numbers in the range
+	return min, max, uint(max-min) == uint(len(s.m)-1)
+}
+
+// Dedup returns a new Set with the distinct items and the ratio of distinct
+// items to all items. The ratio of no items is 1.
+func Dedup(items []interface{}) (*Set, float64) {
+	s := newTS()
+	s.Add(items...)
+
+	if len(items) == 0 {
+		return s, 1
+	}
+	return s, float64(len(s.m)) / float64(len(items))
+}
+
+// FromRange returns a new Set with the ints from start up to, but not
+// including, end by step, like Python's range. A negative step counts down.
+// It returns an empty set if the range is empty and panics if step is zero.
+func FromRange(start, end, step int) *Set {
+	if step == 0 {
+		panic("set: FromRange step must not be zero")
+	}
+
+	s := newTS()
+	if (step > 0 && start >= end) || (step < 0 && start <= end) {
+		return s
+	}
+
+	// the distance to end is computed as uint so that neither it nor i += step
+	// overflow near math.MinInt or math.MaxInt
+	for i := start; ; i += step {
+		s.m[i] = keyExists
+
+		if step > 0 && uint(end)-uint(i) <= uint(step) {
+			break
+		}
+		if step < 0 && uint(i)-uint(end) <= uint(-step) {
+			break
+		}
+	}
+	return s
+}
+
+// RetainWhere returns a new set with the items of s for which member returns
+// true, i.e. the intersection of s with the set defined by member. s is not
+// modified. member is called on a snapshot of s without holding the lock, so
+// it may be slow, e.g. look items up in a database.
+func (s *Set) RetainWhere(member func(item interface{}) bool) *Set {
+	u := s.empty()
+	for _, item := range s.List() {
+		if member(item) {
+			u.m[item] = keyExists
+		}
+	}
+	return u
+}
+
+// RemoveWhere returns a new set with the items of s for which member returns
+// false, i.e. the difference of s and the set defined by member. It's the
+// complement of RetainWhere.
+func (s *Set) RemoveWhere(member func(item interface{}) bool) *Set {
+	return s.RetainWhere(func(item interface{}) bool {
+		return !member(item)
+	})
+}
+
+// AddLines reads newline delimited strings from r and adds them to s. Leading
+// and trailing whitespace, including the CR of CRLF line endings, is trimmed
+// and blank lines are skipped. Lines can be of any length. It returns the
+// number of lines read, including blank ones.
+func (s *Set) AddLines(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+
+	n := 0
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			n++
+			if item := strings.TrimSpace(line); item != "" {
+				s.Add(item)
+			}
+		}
+
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// AddIfUnderLimit adds item to s only if s has less than limit items. It
+// returns whether item is in s afterwards, which is also true if it already
+// was. The check and the addition are done while holding the lock once, so
+// concurrent calls never grow s beyond limit.
+func (s *Set) AddIfUnderLimit(item interface{}, limit int) bool {
+	c := s.lock()
+	defer c.unlock()
+
+	if _, ok := s.m[item]; ok {
+		return true
+	}
+
+	if len(s.m) >= limit {
+		return false
+	}
+
+	c.add(item)
+	return true
+}
+
+// MissingFrom returns a new set with the items of required that are not in s.
+// It's empty exactly when s.IsSubset(required) is true, i.e. when s has all
+// the required items.
+func (s *Set) MissingFrom(required Interface) *Set {
+	items := required.List()
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	u := s.empty()
+	for _, item := range items {
+		if _, ok := s.m[item]; !ok {
+			u.m[u.normalize(item)] = keyExists
+		}
+	}
+	return u
+}
+
+// Patch returns the items that must be added to and removed from s to make it
+// equal to target. Pass them to ApplyPatch to apply them.
+func (s *Set) Patch(target Interface) (toAdd, toRemove *Set) {
+	toAdd = Difference(target, s).(*Set)
+	toRemove = Difference(s, target).(*Set)
+	return toAdd, toRemove
+}
+
+// ApplyPatch adds the items of toAdd to s and then removes the items of
+// toRemove, while holding the lock once. It returns s.
+func (s *Set) ApplyPatch(toAdd, toRemove Interface) *Set {
+	add, remove := toAdd.List(), toRemove.List()
+
+	c := s.lock()
+	defer c.unlock()
+
+	for _, item := range add {
+		c.add(item)
+	}
+	for _, item := range remove {
+		c.remove(item)
+	}
+	return s
+}
+
+// Validate returns the items of s that are not in allowed, sorted in the order
+// defined by DefaultLess. It's empty if all items of s are allowed.
+func (s *Set) Validate(allowed Interface) []interface{} {
+	violations := make([]interface{}, 0)
+	for _, item := range s.List() {
+		if !allowed.Has(item) {
+			violations = append(violations, item)
+		}
+	}
+
+	sortItems(violations)
+	return violations
+}
+
+// Replace deletes oldItem and adds newItem while holding the lock once, so
+// other goroutines never see both or neither of them. newItem is added even
+// if oldItem wasn't in s. It returns whether oldItem was in s.
+func (s *Set) Replace(oldItem, newItem interface{}) bool {
+	c := s.lock()
+	defer c.unlock()
+
+	if _, ok := s.m[oldItem]; ok && oldItem == newItem {
+		return true // nothing changes
+	}
+
+	ok := c.remove(oldItem)
+	c.add(newItem)
+	return ok
+}
+
+// Read calls fn with the underlying map of s while holding the read lock and
+// returns its result. It allows custom read-only computations without copying
+// the items. fn must not modify the map, retain it after returning or call
+// methods of s that modify it.
+func (s *Set) Read(fn func(snapshot map[interface{}]struct{}) interface{}) interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return fn(s.m)
+}
+
+// ToBitmap returns s as a bitmap of max+1 bits, where bit i is set if i is in
+// s. Bit i is stored in byte i/8 at position i%8, counting from the least
+// significant bit. It returns an error if s has items that are not ints or
+// that are outside of [0, max].
+func (s *Set) ToBitmap(max int) ([]byte, error) {
+	if max < 0 {
+		return nil, fmt.Errorf("set: bitmap max %d is negative", max)
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	b := make([]byte, max/8+1)
+	for item := range s.m {
+		i, ok := item.(int)
+		if !ok {
+			return nil, fmt.Errorf("set: item %v of type %T is not an int", item, item)
+		}
+		if i < 0 || i > max {
+			return nil, fmt.Errorf("set: item %d is outside of [0, %d]", i, max)
+		}
+
+		b[i/8] |= 1 << uint(i%8)
+	}
+	return b, nil
+}
+
+// FromBitmap returns a new Set with the ints whose bits are set in b, in the
+// layout used by ToBitmap.
+func FromBitmap(b []byte) *Set {
+	s := newTS()
+	for i, v := range b {
+		for bit := 0; bit < 8; bit++ {
+			if v&(1<<uint(bit)) != 0 {
+				s.m[i*8+bit] = keyExists
+			}
+		}
+	}
+	return s
+}
+
+// PairwiseDistances returns a matrix where the entry [i][j] is the number of
+// items that are in either sets[i] or sets[j], but not in both. Only the upper
+// triangle is computed, with SymmetricDifferenceCount, and mirrored.
+func PairwiseDistances(sets []*Set) [][]int {
+	d := make([][]int, len(sets))
+	for i := range d {
+		d[i] = make([]int, len(sets))
+	}
+
+	for i := range sets {
+		for j := i + 1; j < len(sets); j++ {
+			d[i][j] = sets[i].SymmetricDifferenceCount(sets[j])
+			d[j][i] = d[i][j]
+		}
+	}
+	return d
+}
+
+// StringQuoted is like String, but strings are quoted and escaped with %q, so
+// items containing commas or newlines are unambiguous, e.g. ["a", "b\n", 3].
+func (s *Set) StringQuoted() string {
+	list := s.SortedList()
+
+	t := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			t = append(t, strconv.Quote(str))
+			continue
+		}
+		t = append(t, fmt.Sprintf("%v", item))
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(t, ", "))
+}
+
+// MergePrioritized returns a new Set with the union of the given sets, but
+// with at most max items. Sets are merged in order, so the items of earlier
+// sets are kept in favor of later ones. When a set doesn't fit entirely, its
+// items are added in the order defined by DefaultLess. Nil sets are skipped.
+func MergePrioritized(max int, sets ...Interface) *Set {
+	u := newTS()
+	for _, set := range sets {
+		if isNil(set) {
+			continue
+		}
+
+		if _, truncated := u.MergeCapped(set, max); truncated {
+			break
+		}
+	}
+	return u
+}
+
+// EnvValue returns the string items of s sorted and joined by the platform's
+// path list separator, like the value of PATH. Empty strings and items of
+// other types are skipped.
+func (s *Set) EnvValue() string {
+	strs := s.AppendSortedStrings(nil)
+
+	parts := make([]string, 0, len(strs))
+	for _, str := range strs {
+		if str != "" {
+			parts = append(parts, str)
+		}
+	}
+	return strings.Join(parts, string(os.PathListSeparator))
+}
+
+// FromEnvValue returns a new Set with the parts of v separated by the
+// platform's path list separator, like the value of PATH. Empty parts are
+// skipped.
+func FromEnvValue(v string) *Set {
+	s := newTS()
+	for _, part := range strings.Split(v, string(os.PathListSeparator)) {
+		if part != "" {
+			s.m[part] = keyExists
+		}
+	}
+	return s
+}
+
+// DifferenceChanged returns the items of s that are not in t and whether that
+// is different from s, i.e. whether s and t have any item in common. If they
+// don't, s itself is returned instead of a copy, so the result must not be
+// modified unless changed is true.
+func (s *Set) DifferenceChanged(t Interface) (*Set, bool) {
+	if s.IntersectionCount(t) == 0 {
+		return s, false
+	}
+	return Difference(s, t).(*Set), true
+}
+
+// SingleDiff reports whether s and t differ by exactly one item. If so, it
+// returns that item and a direction of +1 if the item is only in s, or -1 if
+// it's only in t. ok is false if the sets are equal or differ by more than one
+// item. It stops as soon as a second difference is found.
+func (s *Set) SingleDiff(t Interface) (item interface{}, direction int, ok bool) {
+	tItems := t.List() // t may be s, read it before locking
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	switch len(s.m) - len(tItems) {
+	case 1:
+		// every item of t must be in s, which leaves one extra item in s
+		tm := make(map[interface{}]struct{}, len(tItems))
+		for _, i := range tItems {
+			if _, found := s.m[i]; !found {
+				return nil, 0, false
+			}
+			tm[i] = keyExists
+		}
+		for i := range s.m {
+			if _, found := tm[i]; !found {
+				return i, 1, true
+			}
+		}
+	case -1:
+		// every item of s must be in t, which leaves one extra item in t
+		for _, i := range tItems {
+			if _, found := s.m[i]; !found {
+				if ok {
+					return nil, 0, false
+				}
+				item, direction, ok = i, -1, true
+			}
+		}
+		return item, direction, ok
+	}
+
+	// equal sizes differ by zero or at least two items
+	return nil, 0, false
+}
+
+// Update calls fn with the items of s and then adds the items of add and
+// removes the items of remove, all while holding the write lock. It allows a
+// decision based on the items of s to be applied atomically, e.g. trimming s
+// once it grows too large. fn runs under the lock and must not call any
+// methods of s.
+func (s *Set) Update(fn func(members []interface{}) (add, remove []interface{})) {
+	c := s.lock()
+	defer c.unlock()
+
+	members := make([]interface{}, 0, len(s.m))
+	for item := range s.m {
+		members = append(members, item)
+	}
+
+	add, remove := fn(members)
+	for _, item := range add {
+		c.add(item)
+	}
+	for _, item := range remove {
+		c.remove(item)
+	}
+}
+
+// MissingOf returns the passed items that are not in s, in the order they
+// were passed. It's the opposite of calling Has for each item. Items passed
+// more than once are returned as often as they were passed.
+func (s *Set) MissingOf(items ...interface{}) []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	missing := make([]interface{}, 0)
+	for _, item := range items {
+		if _, ok := s.m[item]; !ok {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
+
+// IntRanges returns the int items of s as the minimal list of sorted,
+// inclusive [start, end] ranges, e.g. [[1 3] [5 6] [9 9]] for the items 1, 2,
+// 3, 5, 6 and 9. Items that are not ints are ignored, like IntSlice() does.
+func (s *Set) IntRanges() [][2]int {
+	ints := IntSlice(s)
+	sort.Ints(ints)
+
+	ranges := make([][2]int, 0)
+	for _, v := range ints {
+		if n := len(ranges); n > 0 && ranges[n-1][1] == v-1 {
+			ranges[n-1][1] = v
+			continue
+		}
+		ranges = append(ranges, [2]int{v, v})
+	}
+	return ranges
+}
+
+// MatchRegexp returns a new set with the string items of s that match re.
+// Items that are not strings are skipped.
+func (s *Set) MatchRegexp(re *regexp.Regexp) *Set {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	u := s.empty()
+	for item := range s.m {
+		if v, ok := item.(string); ok && re.MatchString(v) {
+			u.m[item] = keyExists
+		}
+	}
+	return u
+}
+
+// Shards splits the items of s into n disjoint shards and returns an iterator
+// function for each of them, so n goroutines can process s in parallel. Items
+// are assigned to shards by their hash, so the same item is always in the same
+// shard. The shards are taken from a snapshot of s, so iterating them doesn't
+// hold the lock and isn't affected by later changes to s. Like Each(), an
+// iteration stops when f returns false. It panics if n is less than one.
+func (s *Set) Shards(n int) []func(f func(item interface{}) bool) {
+	if n < 1 {
+		panic("set: Shards n must be at least one")
+	}
+
+	parts := make([][]interface{}, n)
+	for _, item := range s.List() {
+		i := hashItem(item) % uint64(n)
+		parts[i] = append(parts[i], item)
+	}
+
+	shards := make([]func(f func(item interface{}) bool), n)
+	for i, part := range parts {
+		shards[i] = func(f func(item interface{}) bool) {
+			for _, item := range part {
+				if !f(item) {
+					return
+				}
+			}
+		}
+	}
+	return shards
+}
+
+// IsCloseWithin reports whether s and t differ by at most k items, i.e.
+// whether their symmetric difference has at most k items. It stops counting
+// as soon as more than k differences are found.
+func (s *Set) IsCloseWithin(t Interface, k int) bool {
+	tItems := t.List() // t may be s, read it before locking
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	// every item of t that is missing in s also leaves one more item of s that
+	// is not in t, so the difference is 2*missing + len(s.m) - len(tItems)
+	diff := len(s.m) - len(tItems)
+	if diff > k || -diff > k {
+		return false
+	}
+
+	for _, item := range tItems {
+		if _, ok := s.m[item]; ok {
+			continue
+		}
+		if diff += 2; diff > k {
+			return false
+		}
+	}
+	return true
+}
+
+// PrecisionRecall treats s as predicted items and reference as the expected
+// ones. precision is the fraction of the items of s that are in reference, and
+// recall is the fraction of the items of reference that are in s. An empty s
+// has a precision of 1, as none of its items is wrong, and an empty reference
+// has a recall of 1, as no items are missing.
+func (s *Set) PrecisionRecall(reference Interface) (precision, recall float64) {
+	common := float64(s.IntersectionCount(reference))
+	size, refSize := s.Size(), reference.Size()
+
+	precision, recall = 1, 1
+	if size > 0 {
+		precision = common / float64(size)
+	}
+	if refSize > 0 {
+		recall = common / float64(refSize)
+	}
+	return precision, recall
+}
+
+// StableVolatile splits the items of s and previous into the stable items,
+// which are in both sets, and the volatile ones, which are in only one of them.
+// It's the intersection and the symmetric difference of the sets, computed in
+// a single pass over each set.
+func (s *Set) StableVolatile(previous Interface) (stable, volatile *Set) {
+	prev := previous.List() // previous may be s, read it before locking
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	stable, volatile = s.empty(), s.empty()
+	for _, item := range prev {
+		if _, ok := s.m[item]; ok {
+			stable.m[stable.normalize(item)] = keyExists
+		} else {
+			volatile.m[volatile.normalize(item)] = keyExists
+		}
+	}
+	for item := range s.m {
+		if _, ok := stable.m[item]; !ok {
+			volatile.m[item] = keyExists
+		}
+	}
+	return stable, volatile
+}
+
+// Filter returns a new set with the items of s for which f returns true. It's
+// like RetainWhere, but f is called while holding the read lock, so s isn't
+// copied first and f must not modify s. Unlike RetainWhere it returns nil if
+// no item matches, so callers can check the result against nil; a nil s
+// returns nil as well.
+func (s *Set) Filter(f func(item interface{}) bool) *Set {
+	if s == nil {
+		return nil
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	u := s.empty()
+	for item := range s.m {
+		if f(item) {
+			u.m[item] = keyExists
+		}
+	}
+
+	if len(u.m) == 0 {
+		return nil
+	}
+	return u
+}
+
+// Map returns a new set with the results of calling f for each item of s. The
+// result can be smaller than s, as items that f maps to the same value are
+// only added once. Like Filter, f is called while holding the read lock and
+// must not modify s, and a nil s returns nil.
+func (s *Set) Map(f func(item interface{}) interface{}) *Set {
+	if s == nil {
+		return nil
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	u := s.empty()
+	for item := range s.m {
+		u.m[u.normalize(f(item))] = keyExists
+	}
+	return u
 }
@@ -1,9 +1,13 @@
 package set
 
 import (
+	"math"
+	"os"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -117,6 +121,16 @@ func TestSet_Pop(t *testing.T) {
 	}
 
 	s.Pop() // try to remove something from a zero length set
+
+	s.Add("again")
+	if !s.Has("again") || s.Pop() != "again" {
+		t.Error("Pop: set should still be usable after popping the last item")
+	}
+
+	var nilSet *Set
+	if nilSet.Pop() != nil {
+		t.Error("Pop: should return nil for a nil set")
+	}
 }
 
 func TestSet_Has(t *testing.T) {
@@ -132,6 +146,19 @@ func TestSet_Has(t *testing.T) {
 	}
 }
 
+func TestSet_Contains(t *testing.T) {
+	s := newTS()
+	s.Add("1", 2)
+
+	if !s.Contains("1") || !s.Contains(2) {
+		t.Error("Contains: added items should exist")
+	}
+
+	if s.Contains("2") || s.Contains(nil) {
+		t.Error("Contains: non-existing items should not exist")
+	}
+}
+
 func TestSet_Clear(t *testing.T) {
 	s := newTS()
 	s.Add(1)
@@ -161,6 +188,27 @@ func TestSet_IsEmpty(t *testing.T) {
 	}
 }
 
+func TestSet_IsEmpty_afterRemoval(t *testing.T) {
+	for name, empty := range map[string]func(s *Set){
+		"Remove": func(s *Set) { s.Remove("1", "2") },
+		"Pop":    func(s *Set) { s.Pop(); s.Pop() },
+		"Clear":  func(s *Set) { s.Clear() },
+	} {
+		s := newTS()
+		s.Add("1", "2")
+		empty(s)
+
+		if !s.IsEmpty() || s.Size() != 0 || s.Pop() != nil || len(s.List()) != 0 {
+			t.Errorf("%s: set should be empty", name)
+		}
+
+		s.Add("3")
+		if s.IsEmpty() || s.Size() != 1 || !s.Has("3") {
+			t.Errorf("%s: an emptied set should still be usable", name)
+		}
+	}
+}
+
 func TestSet_IsEqual(t *testing.T) {
 	// same size, same content
 	s := newTS()
@@ -196,6 +244,78 @@ func TestSet_IsEqual(t *testing.T) {
 	}
 }
 
+// stringSet is a minimal set-like type.
+type stringSet map[string]bool
+
+func (m stringSet) Size() int { return len(m) }
+
+func (m stringSet) List() []interface{} {
+	list := make([]interface{}, 0, len(m))
+	for k := range m {
+		list = append(list, k)
+	}
+	return list
+}
+
+func TestSet_IsEqualI(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2")
+
+	if !s.IsEqualI(stringSet{"1": true, "2": true}) {
+		t.Error("IsEqualI: s should equal the set-like value")
+	}
+
+	if s.IsEqualI(stringSet{"1": true, "3": true}) || s.IsEqualI(stringSet{"1": true}) {
+		t.Error("IsEqualI: s should not equal the set-like value")
+	}
+
+	u := newNonTS()
+	u.Add("1", "2")
+	if !s.IsEqualI(u) {
+		t.Error("IsEqualI: s should equal an Interface with the same items")
+	}
+
+	whileWriting(s, func() {
+		for i := 0; i < 1000; i++ {
+			s.IsEqualI(s)
+		}
+	})
+}
+
+func TestSet_IsEqualExcept(t *testing.T) {
+	ignore := newTS()
+	ignore.Add("pid", "time")
+
+	s := newTS()
+	s.Add("a", "b", "pid")
+	u := newNonTS()
+	u.Add("a", "b", "time")
+
+	if !s.IsEqualExcept(u, ignore) {
+		t.Error("IsEqualExcept: sets only differ in ignored items")
+	}
+
+	u.Add("c")
+	if s.IsEqualExcept(u, ignore) {
+		t.Error("IsEqualExcept: sets differ in an item that is not ignored")
+	}
+
+	u.Remove("a", "c")
+	if s.IsEqualExcept(u, ignore) {
+		t.Error("IsEqualExcept: sets differ in an item that is not ignored")
+	}
+
+	if !s.IsEqualExcept(s, ignore) || !s.IsEqualExcept(s, s) {
+		t.Error("IsEqualExcept: a set should be equal to itself")
+	}
+
+	whileWriting(s, func() {
+		for i := 0; i < 1000; i++ {
+			s.IsEqualExcept(s, s)
+		}
+	})
+}
+
 func TestSet_IsSubset(t *testing.T) {
 	s := newTS()
 	s.Add("1", "2", "3", "4")
@@ -329,3 +449,1755 @@ func TestSet_RaceAdd(t *testing.T) {
 		}(i)
 	}
 }
+
+func TestSet_IntersectionCount(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3", "4")
+	u := newTS()
+	u.Add("3", "4", "5")
+	e := newTS()
+
+	for _, r := range []Interface{u, e, s, newNonTS()} {
+		if got, want := s.IntersectionCount(r), Intersection(s, r).Size(); got != want {
+			t.Errorf("IntersectionCount: got %d, want %d", got, want)
+		}
+	}
+
+	if got := u.IntersectionCount(s); got != 2 {
+		t.Errorf("IntersectionCount: got %d, want 2", got)
+	}
+
+	whileWriting(s, func() {
+		for i := 0; i < 1000; i++ {
+			s.IntersectionCount(s)
+		}
+	})
+}
+
+func TestSet_AddSafe(t *testing.T) {
+	s := newTS()
+
+	if err := s.AddSafe("1", 2, 3.14, struct{ a int }{1}); err != nil {
+		t.Errorf("AddSafe: comparable items should be added, got error: %s", err)
+	}
+
+	if s.Size() != 4 {
+		t.Error("AddSafe: the set size should be four")
+	}
+
+	type wrapper struct{ v interface{} }
+
+	bad := []interface{}{
+		[]int{1, 2},
+		map[string]int{"a": 1},
+		func() {},
+		wrapper{[]int{1}},
+	}
+
+	for _, item := range bad {
+		if err := s.AddSafe("5", item); err == nil {
+			t.Errorf("AddSafe: adding %T should return an error", item)
+		}
+	}
+
+	if s.Has("5") {
+		t.Error("AddSafe: no item should be added if one of them is not comparable")
+	}
+}
+
+func TestSet_DeepCopy(t *testing.T) {
+	type config struct{ name string }
+
+	c := &config{name: "fatih"}
+	s := newTS()
+	s.Add(c)
+
+	u := s.DeepCopy(func(item interface{}) interface{} {
+		v := *item.(*config)
+		return &v
+	})
+
+	if u.Size() != 1 || u.Has(c) {
+		t.Fatal("DeepCopy: copy should contain a single cloned item")
+	}
+
+	u.Pop().(*config).name = "arne"
+	if c.name != "fatih" {
+		t.Error("DeepCopy: mutating a cloned item should not affect the original")
+	}
+
+	if !s.DeepCopy(nil).IsEqual(s) {
+		t.Error("DeepCopy: a nil clone func should return a shallow copy")
+	}
+}
+
+func TestSet_Clone(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3", "4")
+	r := s.Clone()
+
+	if !s.IsEqual(r) {
+		t.Error("Clone: set s and r are not equal")
+	}
+
+	r.Add("5")
+	if s.Has("5") {
+		t.Error("Clone: modifying the clone should not modify the original set")
+	}
+}
+
+func TestSet_Page(t *testing.T) {
+	s := newTS()
+	for i := 0; i < 25; i++ {
+		s.Add(i)
+	}
+
+	var pages []interface{}
+	for offset := 0; offset < 30; offset += 10 {
+		pages = append(pages, s.Page(offset, 10)...)
+	}
+
+	if len(pages) != 25 {
+		t.Fatalf("Page: concatenated pages should have 25 items, got %d", len(pages))
+	}
+
+	for i, item := range pages {
+		if item != i {
+			t.Fatalf("Page: item %d should be %d, got %v", i, i, item)
+		}
+	}
+
+	if len(s.Page(25, 10)) != 0 || len(s.Page(-1, 10)) != 0 || len(s.Page(0, 0)) != 0 {
+		t.Error("Page: out of range pages should be empty")
+	}
+}
+
+func TestSet_TopK(t *testing.T) {
+	s := newTS()
+	s.Add(5, 3, 9, 1, 7, -7)
+
+	abs := func(item interface{}) float64 {
+		v := item.(int)
+		if v < 0 {
+			v = -v
+		}
+		return float64(v)
+	}
+
+	// 7 and -7 have the same score and are ordered by value
+	top := s.TopK(3, abs)
+	if !reflect.DeepEqual(top, []interface{}{9, -7, 7}) {
+		t.Errorf("TopK: got %v, want [9 -7 7]", top)
+	}
+
+	if len(s.TopK(10, abs)) != 6 {
+		t.Error("TopK: k should be clamped to the set size")
+	}
+
+	if len(s.TopK(0, abs)) != 0 {
+		t.Error("TopK: k of zero should return no items")
+	}
+}
+
+func TestSet_EqualsMapKeys(t *testing.T) {
+	s := newTS()
+	s.Add("a", "b")
+
+	if !s.EqualsMapKeys(map[string]int{"a": 1, "b": 2}) {
+		t.Error("EqualsMapKeys: set should equal the keys of the map")
+	}
+
+	if s.EqualsMapKeys(map[string]int{"a": 1, "c": 2}) {
+		t.Error("EqualsMapKeys: set should not equal the keys of a map with other keys")
+	}
+
+	u := newTS()
+	u.Add(1, 2, 3)
+
+	if !u.EqualsMapKeys(map[int]struct{}{1: {}, 2: {}, 3: {}}) {
+		t.Error("EqualsMapKeys: set should equal the keys of the map")
+	}
+
+	if u.EqualsMapKeys(map[int]struct{}{1: {}, 2: {}}) {
+		t.Error("EqualsMapKeys: set should not equal the keys of a smaller map")
+	}
+
+	if u.EqualsMapKeys([]int{1, 2, 3}) || u.EqualsMapKeys(nil) {
+		t.Error("EqualsMapKeys: non-map arguments should return false")
+	}
+}
+
+func TestSet_Each(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2, 3, 4)
+
+	visited := 0
+	s.Each(func(item interface{}) bool {
+		visited++
+		return visited < 2
+	})
+
+	if visited != 2 {
+		t.Errorf("Each: should stop when f returns false, visited %d items", visited)
+	}
+
+	var nilSet *Set
+	nilSet.Each(func(item interface{}) bool {
+		t.Error("Each: f should not be called for a nil set")
+		return true
+	})
+}
+
+func TestSet_EachIndexed(t *testing.T) {
+	s := newTS()
+	s.Add("c", "a", "d", "b")
+
+	var got []string
+	s.EachIndexed(func(i int, item interface{}) bool {
+		got = append(got, strconv.Itoa(i)+item.(string))
+		return i < 2
+	})
+
+	if want := []string{"0a", "1b", "2c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EachIndexed: got %v, want %v", got, want)
+	}
+}
+
+func TestSet_SymmetricDifferenceCount(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3", "4")
+
+	others := [][]interface{}{
+		{},
+		{"1", "2", "3", "4"},
+		{"3", "4", "5"},
+		{"5", "6"},
+		{"1"},
+	}
+
+	for _, items := range others {
+		u := newNonTS()
+		u.Add(items...)
+
+		if got, want := s.SymmetricDifferenceCount(u), SymmetricDifference(s, u).Size(); got != want {
+			t.Errorf("SymmetricDifferenceCount(%v): got %d, want %d", items, got, want)
+		}
+	}
+
+	if got := s.SymmetricDifferenceCount(s); got != 0 {
+		t.Errorf("SymmetricDifferenceCount: a set should not differ from itself, got %d", got)
+	}
+
+	whileWriting(s, func() {
+		for i := 0; i < 1000; i++ {
+			s.SymmetricDifferenceCount(s)
+		}
+	})
+}
+
+func TestSet_RemoveFunc(t *testing.T) {
+	s := newTS()
+	for i := 0; i < 10; i++ {
+		s.Add(i)
+	}
+
+	n := s.RemoveFunc(func(item interface{}) bool {
+		return item.(int)%3 == 0
+	})
+
+	if n != 4 {
+		t.Errorf("RemoveFunc: should remove four items, got %d", n)
+	}
+
+	u := newTS()
+	u.Add(1, 2, 4, 5, 7, 8)
+	if !s.IsEqual(u) {
+		t.Errorf("RemoveFunc: remaining items should be %s, got %s", u, s)
+	}
+}
+
+func TestSet_RetainFunc(t *testing.T) {
+	s := newTS()
+	for i := 0; i < 10; i++ {
+		s.Add(i)
+	}
+
+	n := s.RetainFunc(func(item interface{}) bool {
+		return item.(int)%3 == 0
+	})
+
+	if n != 4 {
+		t.Errorf("RetainFunc: should retain four items, got %d", n)
+	}
+
+	u := newTS()
+	u.Add(0, 3, 6, 9)
+	if !s.IsEqual(u) {
+		t.Errorf("RetainFunc: remaining items should be %s, got %s", u, s)
+	}
+}
+
+func TestSet_DifferenceSeq(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3", "4")
+	u := newNonTS()
+	u.Add("3", "4", "5")
+
+	got := newTS()
+	for item := range s.DifferenceSeq(u) {
+		got.Add(item)
+	}
+
+	if !got.IsEqual(Difference(s, u)) {
+		t.Errorf("DifferenceSeq: got %s, want %s", got, Difference(s, u))
+	}
+
+	n := 0
+	for range s.DifferenceSeq(u) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Error("DifferenceSeq: iteration should stop on break")
+	}
+}
+
+func TestSet_MergeCapped(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2)
+	u := newTS()
+	u.Add(2, 3, 4)
+
+	added, truncated := s.MergeCapped(u, 10)
+	if added != 2 || truncated || s.Size() != 4 {
+		t.Errorf("MergeCapped: under the cap all items should be added, got %d, %v", added, truncated)
+	}
+
+	s = newTS()
+	s.Add(1, 2)
+	u.Add(5, 6)
+
+	added, truncated = s.MergeCapped(u, 4)
+	if added != 2 || !truncated || s.Size() != 4 {
+		t.Errorf("MergeCapped: over the cap only two items should be added, got %d, %v", added, truncated)
+	}
+
+	if !s.Has(1, 2, 3, 4) {
+		t.Errorf("MergeCapped: the smallest items should be added first, got %s", s)
+	}
+}
+
+func TestSet_StringN(t *testing.T) {
+	s := newTS()
+	s.Add(5, 4, 3, 2, 1)
+
+	tests := []struct {
+		max  int
+		want string
+	}{
+		{0, "[... (+5 more)]"},
+		{2, "[1, 2, ... (+3 more)]"},
+		{4, "[1, 2, 3, 4, ... (+1 more)]"},
+		{5, "[1, 2, 3, 4, 5]"},
+		{10, "[1, 2, 3, 4, 5]"},
+	}
+
+	for _, tt := range tests {
+		if got := s.StringN(tt.max); got != tt.want {
+			t.Errorf("StringN(%d): got %s, want %s", tt.max, got, tt.want)
+		}
+	}
+
+	if got := newTS().StringN(2); got != "[]" {
+		t.Errorf("StringN: empty set should print [], got %s", got)
+	}
+}
+
+func TestSet_Swap(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3")
+	u := newTS()
+	u.Add("a", "b")
+
+	old := s.Swap(u)
+	if !s.IsEqual(u) {
+		t.Errorf("Swap: s should have the new items, got %s", s)
+	}
+	if old.Size() != 3 || !old.Has("1", "2", "3") {
+		t.Errorf("Swap: should return the previous items, got %s", old)
+	}
+
+	u.Add("c")
+	if s.Has("c") {
+		t.Error("Swap: s should not share the items of t")
+	}
+}
+
+func TestSet_Swap_race(t *testing.T) {
+	a := newTS()
+	a.Add("1", "2", "3")
+	b := newTS()
+	b.Add("a", "b")
+
+	s := a.Clone()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				s.Swap(b)
+			} else {
+				s.Swap(a)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if c := s.Clone(); !c.IsEqual(a) && !c.IsEqual(b) {
+			t.Fatalf("Swap: readers should see either the old or new items, got %s", c)
+		}
+	}
+}
+
+func TestSet_EachRemove(t *testing.T) {
+	s := newTS()
+	for i := 0; i < 100; i++ {
+		s.Add(i)
+	}
+
+	even := func(item interface{}) bool {
+		return item.(int)%2 == 0
+	}
+
+	// concurrent calls must remove each item exactly once
+	counts := make(chan int)
+	for i := 0; i < 4; i++ {
+		go func() {
+			counts <- s.EachRemove(even)
+		}()
+	}
+
+	total := 0
+	for i := 0; i < 4; i++ {
+		total += <-counts
+	}
+
+	if total != 50 {
+		t.Errorf("EachRemove: should remove 50 items in total, got %d", total)
+	}
+
+	if s.Size() != 50 || s.Has(0) || !s.Has(1) {
+		t.Errorf("EachRemove: only odd items should be left, got %s", s)
+	}
+}
+
+func TestSet_SubtractReport(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3", "4")
+	u := newNonTS()
+	u.Add("3", "4", "5")
+
+	want := Intersection(s, u)
+	removed := s.SubtractReport(u)
+
+	got := newTS()
+	got.Add(removed...)
+	if len(removed) != 2 || !got.IsEqual(want) {
+		t.Errorf("SubtractReport: got %v, want %s", removed, want)
+	}
+
+	if s.Size() != 2 || !s.Has("1", "2") {
+		t.Errorf("SubtractReport: s should be modified, got %s", s)
+	}
+}
+
+func TestSet_Combinations(t *testing.T) {
+	s := newTS()
+	s.Add(4, 2, 3, 1)
+
+	want := [][]interface{}{{1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}}
+	if got := s.Combinations(2); !reflect.DeepEqual(got, want) {
+		t.Errorf("Combinations: got %v, want %v", got, want)
+	}
+
+	if got := s.Combinations(4); len(got) != 1 || len(got[0]) != 4 {
+		t.Errorf("Combinations: should return a single combination of all items, got %v", got)
+	}
+
+	if got := s.Combinations(5); len(got) != 0 {
+		t.Errorf("Combinations: k larger than the set should return none, got %v", got)
+	}
+
+	u := newTS()
+	for i := 0; i < 100; i++ {
+		u.Add(i)
+	}
+
+	if u.Combinations(50) != nil {
+		t.Error("Combinations: should return nil above MaxCombinations")
+	}
+
+	n := 0
+	u.EachCombination(50, func(comb []interface{}) bool {
+		n++
+		return n < 10
+	})
+	if n != 10 {
+		t.Errorf("EachCombination: iteration should stop when f returns false, got %d", n)
+	}
+}
+
+func TestSet_AddReportDuplicates(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2")
+
+	dups := s.AddReportDuplicates("2", "3", "4", "3")
+	if !reflect.DeepEqual(dups, []interface{}{"2", "3"}) {
+		t.Errorf("AddReportDuplicates: got %v, want [2 3]", dups)
+	}
+
+	if s.Size() != 4 || !s.Has("1", "2", "3", "4") {
+		t.Errorf("AddReportDuplicates: all items should be added, got %s", s)
+	}
+
+	if dups := s.AddReportDuplicates("5"); dups != nil {
+		t.Errorf("AddReportDuplicates: should not report new items, got %v", dups)
+	}
+}
+
+func TestSet_Sum(t *testing.T) {
+	s := newTS()
+	s.Add("1", 2, 3, 1.5, 2.5, 4.0, int64(7))
+
+	if sum, ok := s.SumInt(); !ok || sum != 5 {
+		t.Errorf("SumInt: got %d, %v, want 5, true", sum, ok)
+	}
+
+	if sum, ok := s.SumFloat64(); !ok || sum != 8 {
+		t.Errorf("SumFloat64: got %v, %v, want 8, true", sum, ok)
+	}
+
+	if mean, ok := s.MeanFloat64(); !ok || mean != 8.0/3 {
+		t.Errorf("MeanFloat64: got %v, %v, want %v, true", mean, ok, 8.0/3)
+	}
+
+	u := newTS()
+	u.Add("a", "b")
+
+	_, okInt := u.SumInt()
+	_, okFloat := u.SumFloat64()
+	_, okMean := u.MeanFloat64()
+	if okInt || okFloat || okMean {
+		t.Error("Sum: sets without numeric items should return false")
+	}
+}
+
+func TestSet_IsSupersetOfSlice(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3")
+
+	if !s.IsSupersetOfSlice([]interface{}{"1", "2", "2", "1"}) {
+		t.Error("IsSupersetOfSlice: s contains all items of the slice")
+	}
+
+	if s.IsSupersetOfSlice([]interface{}{"1", "4", "1"}) {
+		t.Error("IsSupersetOfSlice: s doesn't contain all items of the slice")
+	}
+
+	if !s.IsSupersetOfSlice(nil) || !s.IsSupersetOfSlice([]interface{}{}) {
+		t.Error("IsSupersetOfSlice: any set is a superset of an empty slice")
+	}
+}
+
+func TestSet_IsSubsetOfSlice(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3")
+
+	if !s.IsSubsetOfSlice([]interface{}{"1", "2", "2", "3", "4"}) {
+		t.Error("IsSubsetOfSlice: all items of s are in the slice")
+	}
+
+	if s.IsSubsetOfSlice([]interface{}{"1", "2", "2", "1"}) {
+		t.Error("IsSubsetOfSlice: not all items of s are in the slice")
+	}
+
+	if s.IsSubsetOfSlice(nil) || !newTS().IsSubsetOfSlice(nil) || !newTS().IsSubsetOfSlice([]interface{}{}) {
+		t.Error("IsSubsetOfSlice: only an empty set is a subset of an empty slice")
+	}
+}
+
+func TestSet_SplitBySize(t *testing.T) {
+	s := newTS()
+	for i := 0; i < 2500; i++ {
+		s.Add(i)
+	}
+
+	chunks := s.SplitBySize(1000)
+	if len(chunks) != 3 {
+		t.Fatalf("SplitBySize: should return three chunks, got %d", len(chunks))
+	}
+
+	for i, want := range []int{1000, 1000, 500} {
+		if chunks[i].Size() != want {
+			t.Errorf("SplitBySize: chunk %d should have %d items, got %d", i, want, chunks[i].Size())
+		}
+	}
+
+	if !chunks[0].Has(0, 999) || !chunks[2].Has(2000, 2499) {
+		t.Error("SplitBySize: items should be assigned in sorted order")
+	}
+
+	if !UnionAll(chunks[0], chunks[1], chunks[2]).IsEqual(s) {
+		t.Error("SplitBySize: chunks should contain all items")
+	}
+
+	if len(newTS().SplitBySize(10)) != 0 || s.SplitBySize(0) != nil {
+		t.Error("SplitBySize: empty sets and invalid sizes should return no chunks")
+	}
+}
+
+func TestSet_IntersectsAny(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3")
+
+	a := newTS()
+	a.Add("4", "5")
+	b := newNonTS()
+	b.Add("6")
+	c := newTS()
+	c.Add("7", "8", "9", "3", "10")
+	var nilSet *Set
+
+	if !s.IntersectsAny(a, nil, nilSet, b, c) {
+		t.Error("IntersectsAny: s has an item in common with the last set")
+	}
+
+	if s.IntersectsAny(a, nil, b) {
+		t.Error("IntersectsAny: s has no item in common with any set")
+	}
+
+	if s.IntersectsAny() {
+		t.Error("IntersectsAny: should be false without any sets")
+	}
+
+	if !s.IntersectsAny(a, s) {
+		t.Error("IntersectsAny: s has items in common with itself")
+	}
+
+	whileWriting(s, func() {
+		for i := 0; i < 1000; i++ {
+			s.IntersectsAny(s)
+		}
+	})
+}
+
+func TestSet_Snapshot(t *testing.T) {
+	s := newTS()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			s.Add(i)
+			if i%3 == 0 {
+				s.Remove(i / 2)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if size, items := s.Snapshot(); size != len(items) {
+			t.Fatalf("Snapshot: size %d should equal the number of items %d", size, len(items))
+		}
+	}
+}
+
+func TestSet_RemoveIntersection(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3", "4")
+	u := newNonTS()
+	u.Add("3", "4", "5")
+
+	want := Difference(s, u)
+	if r := s.RemoveIntersection(u); r != s {
+		t.Error("RemoveIntersection: should return the receiver")
+	}
+
+	if !s.IsEqual(want) {
+		t.Errorf("RemoveIntersection: got %s, want %s", s, want)
+	}
+}
+
+func TestSet_RetainAll(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3", "4")
+	u := newNonTS()
+	u.Add("3", "4", "5")
+
+	want := Intersection(s, u)
+	if r := s.RetainAll(u); r != s {
+		t.Error("RetainAll: should return the receiver")
+	}
+
+	if !s.IsEqual(want) {
+		t.Errorf("RetainAll: got %s, want %s", s, want)
+	}
+}
+
+func TestSet_RetainAll_self(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2")
+
+	if s.RetainAll(s); s.Size() != 2 {
+		t.Error("RetainAll: retaining s in itself should not change it")
+	}
+
+	if s.RemoveIntersection(s); !s.IsEmpty() {
+		t.Error("RemoveIntersection: removing s from itself should empty it")
+	}
+}
+
+func TestSet_ChangesSince(t *testing.T) {
+	old := newTS()
+	old.Add("a", "b", "c", "d")
+	s := newTS()
+	s.Add("c", "f", "b", "e")
+
+	added, removed := s.ChangesSince(old)
+	if !reflect.DeepEqual(added, []interface{}{"e", "f"}) {
+		t.Errorf("ChangesSince: got added %v, want [e f]", added)
+	}
+	if !reflect.DeepEqual(removed, []interface{}{"a", "d"}) {
+		t.Errorf("ChangesSince: got removed %v, want [a d]", removed)
+	}
+
+	replay := old.Clone()
+	for _, item := range added {
+		replay.Add(item)
+	}
+	for _, item := range removed {
+		replay.Remove(item)
+	}
+	if !replay.IsEqual(s) {
+		t.Errorf("ChangesSince: replaying the changes should give %s, got %s", s, replay)
+	}
+}
+
+func TestFromMapKeys(t *testing.T) {
+	s, err := FromMapKeys(map[string]int{"a": 1, "b": 2, "c": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Size() != 3 || !s.Has("a", "b", "c") {
+		t.Errorf("FromMapKeys: got %s, want [a, b, c]", s)
+	}
+
+	if _, err := FromMapKeys([]string{"a"}); err == nil {
+		t.Error("FromMapKeys: non-map arguments should return an error")
+	}
+}
+
+func TestFromMapValues(t *testing.T) {
+	s, err := FromMapValues(map[string]int{"a": 1, "b": 2, "c": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Size() != 2 || !s.Has(1, 2) {
+		t.Errorf("FromMapValues: got %s, want [1, 2]", s)
+	}
+
+	if _, err := FromMapValues(map[string][]int{"a": {1}}); err == nil {
+		t.Error("FromMapValues: uncomparable values should return an error")
+	}
+
+	if _, err := FromMapValues(nil); err == nil {
+		t.Error("FromMapValues: non-map arguments should return an error")
+	}
+}
+
+func TestSet_KindCounts(t *testing.T) {
+	s := newTS()
+	s.Add("a", "b", 1, 2, 3, 1.5, nil)
+
+	want := map[reflect.Kind]int{
+		reflect.String:  2,
+		reflect.Int:     3,
+		reflect.Float64: 1,
+		reflect.Invalid: 1,
+	}
+	if got := s.KindCounts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("KindCounts: got %v, want %v", got, want)
+	}
+}
+
+func TestSet_KindEntropy(t *testing.T) {
+	s := newTS()
+	if h := s.KindEntropy(); h != 0 {
+		t.Errorf("KindEntropy: empty set should be 0, got %v", h)
+	}
+
+	s.Add("a", "b", "c")
+	if h := s.KindEntropy(); h != 0 {
+		t.Errorf("KindEntropy: set of a single kind should be 0, got %v", h)
+	}
+
+	s.Add(1, 2, 3)
+	if h := s.KindEntropy(); h != 1 {
+		t.Errorf("KindEntropy: evenly mixed set of two kinds should be 1, got %v", h)
+	}
+}
+
+func TestSet_MergeAll(t *testing.T) {
+	s := newTS()
+	s.Add(-1)
+
+	shards := make([]Interface, 0, 10)
+	for i := 0; i < 10; i++ {
+		u := newNonTS()
+		for j := 0; j < 100; j++ {
+			u.Add(i*100 + j)
+		}
+		shards = append(shards, u)
+	}
+	shards = append(shards, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if size := s.Size(); size != 1 && size != 1001 {
+				t.Errorf("MergeAll: readers should not see a partial merge, got size %d", size)
+				return
+			} else if size == 1001 {
+				return
+			}
+		}
+	}()
+
+	if r := s.MergeAll(shards...); r != s {
+		t.Error("MergeAll: should return the receiver")
+	}
+	<-done
+
+	if s.Size() != 1001 || !s.Has(-1, 0, 999) {
+		t.Errorf("MergeAll: all items should be merged, got size %d", s.Size())
+	}
+}
+
+func TestSet_SubsetSeeded(t *testing.T) {
+	s := newTS()
+	u := newTS()
+	for i := 0; i < 100; i++ {
+		s.Add(i)
+		u.Add(99 - i)
+	}
+
+	a := s.SubsetSeeded(10, 42)
+	b := u.SubsetSeeded(10, 42)
+	if a.Size() != 10 || !a.IsEqual(b) {
+		t.Errorf("SubsetSeeded: same seed should give the same subset, got %s and %s", a, b)
+	}
+
+	if !s.IsSubset(a) {
+		t.Error("SubsetSeeded: subset items should be in the set")
+	}
+
+	if s.SubsetSeeded(10, 43).IsEqual(a) {
+		t.Error("SubsetSeeded: different seeds should give different subsets")
+	}
+
+	if s.SubsetSeeded(200, 1).Size() != 100 || !s.SubsetSeeded(0, 1).IsEmpty() {
+		t.Error("SubsetSeeded: n should be clamped to the set size")
+	}
+}
+
+func TestSet_Drain(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2)
+
+	seen := make(map[interface{}]int)
+	s.Drain(func(item interface{}) {
+		seen[item]++
+
+		// discover the next two numbers up to 10
+		if n := item.(int); n < 10 {
+			s.Add(n*2+1, n*2+2)
+		}
+	})
+
+	if !s.IsEmpty() {
+		t.Errorf("Drain: set should be empty, got %s", s)
+	}
+
+	// numbers below 10 discover everything up to 20
+	for i := 1; i <= 20; i++ {
+		if seen[i] == 0 {
+			t.Errorf("Drain: %d should be processed", i)
+		}
+	}
+
+	removed := 0
+	s.OnRemove(func(item interface{}) { removed++ })
+	s.Add(nil, 1, 2, 3)
+
+	processed := 0
+	s.Drain(func(item interface{}) { processed++ })
+	if processed != 4 || !s.IsEmpty() {
+		t.Errorf("Drain: a nil item should not stop draining, processed %d items, left %s", processed, s)
+	}
+	if removed != 4 {
+		t.Errorf("Drain: OnRemove should be called for each popped item, got %d calls", removed)
+	}
+}
+
+func TestSet_AppendStrings(t *testing.T) {
+	s := newTS()
+	s.Add("c", "a", 1, "b", 2)
+
+	got := s.AppendSortedStrings([]string{"z"})
+	if !reflect.DeepEqual(got, []string{"z", "a", "b", "c"}) {
+		t.Errorf("AppendSortedStrings: got %v, want [z a b c]", got)
+	}
+
+	if got := s.AppendStrings([]string{"z"}); len(got) != 4 || got[0] != "z" {
+		t.Errorf("AppendStrings: got %v", got)
+	}
+}
+
+func TestSet_AppendInts(t *testing.T) {
+	s := newTS()
+	s.Add(3, "a", 1, 2)
+
+	got := s.AppendSortedInts([]int{9})
+	if !reflect.DeepEqual(got, []int{9, 1, 2, 3}) {
+		t.Errorf("AppendSortedInts: got %v, want [9 1 2 3]", got)
+	}
+
+	if got := s.AppendInts([]int{9}); len(got) != 4 || got[0] != 9 {
+		t.Errorf("AppendInts: got %v", got)
+	}
+}
+
+func TestSet_IsContiguousIntRange(t *testing.T) {
+	s := newTS()
+	s.Add(5, 3, 4, 2)
+
+	if min, max, ok := s.IsContiguousIntRange(); !ok || min != 2 || max != 5 {
+		t.Errorf("IsContiguousIntRange: got %d, %d, %v, want 2, 5, true", min, max, ok)
+	}
+
+	s.Add(7)
+	if min, max, ok := s.IsContiguousIntRange(); ok || min != 2 || max != 7 {
+		t.Errorf("IsContiguousIntRange: got %d, %d, %v, want 2, 7, false", min, max, ok)
+	}
+
+	s.Add(6, "8")
+	if _, _, ok := s.IsContiguousIntRange(); ok {
+		t.Error("IsContiguousIntRange: sets with non-int items should not be a range")
+	}
+
+	if _, _, ok := newTS().IsContiguousIntRange(); ok {
+		t.Error("IsContiguousIntRange: empty sets should not be a range")
+	}
+}
+
+func TestDedup(t *testing.T) {
+	s, ratio := Dedup([]interface{}{"a", "b", "a", "c", "b", "d", "a", "a"})
+	if s.Size() != 4 || !s.Has("a", "b", "c", "d") {
+		t.Errorf("Dedup: got %s, want [a, b, c, d]", s)
+	}
+
+	if ratio != 0.5 {
+		t.Errorf("Dedup: ratio should be 0.5, got %v", ratio)
+	}
+
+	if s, ratio := Dedup(nil); !s.IsEmpty() || ratio != 1 {
+		t.Errorf("Dedup: no items should give an empty set and a ratio of 1, got %v", ratio)
+	}
+}
+
+func TestFromRange(t *testing.T) {
+	tests := []struct {
+		start, end, step int
+		want             []int
+	}{
+		{0, 10, 2, []int{0, 2, 4, 6, 8}},
+		{0, 3, 1, []int{0, 1, 2}},
+		{5, 0, -2, []int{1, 3, 5}},
+		{0, 0, 1, []int{}},
+		{5, 0, 1, []int{}},
+		{0, 5, -1, []int{}},
+		{math.MaxInt - 2, math.MaxInt, 1, []int{math.MaxInt - 2, math.MaxInt - 1}},
+		{math.MaxInt - 5, math.MaxInt, 3, []int{math.MaxInt - 5, math.MaxInt - 2}},
+		{math.MinInt + 2, math.MinInt, -1, []int{math.MinInt + 1, math.MinInt + 2}},
+		{math.MinInt + 5, math.MinInt, -3, []int{math.MinInt + 2, math.MinInt + 5}},
+		{math.MinInt, math.MaxInt, math.MaxInt, []int{math.MinInt, -1, math.MaxInt - 1}},
+		{math.MaxInt, math.MinInt, math.MinInt, []int{-1, math.MaxInt}},
+	}
+
+	for _, tt := range tests {
+		s := FromRange(tt.start, tt.end, tt.step)
+		if got := s.AppendSortedInts(nil); len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("FromRange(%d, %d, %d): got %v, want %v", tt.start, tt.end, tt.step, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("FromRange: a zero step should panic")
+		}
+	}()
+	FromRange(0, 10, 0)
+}
+
+func TestSet_RetainWhere(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2, 3, 4, 5)
+
+	db := map[interface{}]bool{2: true, 4: true, 6: true}
+	member := func(item interface{}) bool { return db[item] }
+
+	u := s.RetainWhere(member)
+	if u.Size() != 2 || !u.Has(2, 4) {
+		t.Errorf("RetainWhere: got %s, want [2, 4]", u)
+	}
+
+	r := s.RemoveWhere(member)
+	if r.Size() != 3 || !r.Has(1, 3, 5) {
+		t.Errorf("RemoveWhere: got %s, want [1, 3, 5]", r)
+	}
+
+	if s.Size() != 5 {
+		t.Error("RetainWhere: the receiver should not be modified")
+	}
+}
+
+func TestSet_AddLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	input := "a\r\n  b \n\nc\na\n" + long + "\nd"
+
+	s := newTS()
+	n, err := s.AddLines(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 7 {
+		t.Errorf("AddLines: should read seven lines, got %d", n)
+	}
+
+	if s.Size() != 5 || !s.Has("a", "b", "c", "d", long) {
+		t.Errorf("AddLines: got %s", s.StringN(4))
+	}
+}
+
+func TestSet_AddIfUnderLimit(t *testing.T) {
+	const limit = 10
+
+	s := newTS()
+	results := make(chan bool)
+	for i := 0; i < 100; i++ {
+		go func(i int) {
+			results <- s.AddIfUnderLimit(i, limit)
+		}(i)
+	}
+
+	added := 0
+	for i := 0; i < 100; i++ {
+		if <-results {
+			added++
+		}
+	}
+
+	if added != limit || s.Size() != limit {
+		t.Errorf("AddIfUnderLimit: %d items should be added, got %d and size %d", limit, added, s.Size())
+	}
+
+	existing := s.List()[0]
+	if !s.AddIfUnderLimit(existing, limit) {
+		t.Error("AddIfUnderLimit: an existing item should be reported as a member")
+	}
+
+	if s.AddIfUnderLimit("new", limit) {
+		t.Error("AddIfUnderLimit: a new item should not be added over the limit")
+	}
+}
+
+func TestSet_MissingFrom(t *testing.T) {
+	s := newTS()
+	s.Add("read", "write")
+	required := newNonTS()
+	required.Add("read", "write", "admin", "delete")
+
+	missing := s.MissingFrom(required)
+	if missing.Size() != 2 || !missing.Has("admin", "delete") {
+		t.Errorf("MissingFrom: got %s, want [admin, delete]", missing)
+	}
+
+	s.Add("admin", "delete", "other")
+	if !s.IsSubset(required) || !s.MissingFrom(required).IsEmpty() {
+		t.Error("MissingFrom: nothing should be missing if s has all required items")
+	}
+}
+
+func TestSet_Patch(t *testing.T) {
+	s := newTS()
+	s.Add("a", "b", "c")
+	target := newNonTS()
+	target.Add("b", "c", "d", "e")
+
+	toAdd, toRemove := s.Patch(target)
+	if toAdd.Size() != 2 || !toAdd.Has("d", "e") {
+		t.Errorf("Patch: got toAdd %s, want [d, e]", toAdd)
+	}
+	if toRemove.Size() != 1 || !toRemove.Has("a") {
+		t.Errorf("Patch: got toRemove %s, want [a]", toRemove)
+	}
+
+	if r := s.ApplyPatch(s.Patch(target)); r != s || !s.IsEqual(target) {
+		t.Errorf("ApplyPatch: got %s, want %s", s, target)
+	}
+}
+
+func TestSet_Validate(t *testing.T) {
+	allowed := newNonTS()
+	allowed.Add("go", "rust", "c")
+
+	s := newTS()
+	s.Add("go", "php", "c", "cobol")
+
+	if got := s.Validate(allowed); !reflect.DeepEqual(got, []interface{}{"cobol", "php"}) {
+		t.Errorf("Validate: got %v, want [cobol php]", got)
+	}
+
+	s.Remove("php", "cobol")
+	if got := s.Validate(allowed); len(got) != 0 {
+		t.Errorf("Validate: all items are allowed, got %v", got)
+	}
+}
+
+func TestSet_Replace(t *testing.T) {
+	s := newTS()
+	s.Add("token0")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if !s.Replace("token"+strconv.Itoa(i), "token"+strconv.Itoa(i+1)) {
+				t.Errorf("Replace: token%d should exist", i)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			if !s.Has("token1000") || s.Size() != 1 {
+				t.Errorf("Replace: only the last token should exist, got %s", s)
+			}
+			return
+		default:
+		}
+
+		if size, items := s.Snapshot(); size != 1 {
+			t.Fatalf("Replace: readers should see exactly one token, got %v", items)
+		}
+	}
+}
+
+func TestSet_Read(t *testing.T) {
+	s := newTS()
+	s.Add("a", "bb", "ccc", 4)
+
+	total := s.Read(func(m map[interface{}]struct{}) interface{} {
+		n := 0
+		for item := range m {
+			if str, ok := item.(string); ok {
+				n += len(str)
+			}
+		}
+		return n
+	})
+
+	if total != 6 {
+		t.Errorf("Read: got %v, want 6", total)
+	}
+}
+
+func TestSet_ToBitmap(t *testing.T) {
+	s := newTS()
+	s.Add(0, 3, 7, 9)
+
+	b, err := s.ToBitmap(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(b, []byte{0x89, 0x02}) {
+		t.Errorf("ToBitmap: got %x, want 8902", b)
+	}
+
+	if u := FromBitmap(b); !u.IsEqual(s) {
+		t.Errorf("FromBitmap: got %s, want %s", u, s)
+	}
+
+	if _, err := s.ToBitmap(8); err == nil {
+		t.Error("ToBitmap: items above max should return an error")
+	}
+
+	s.Add("1")
+	if _, err := s.ToBitmap(15); err == nil {
+		t.Error("ToBitmap: non-int items should return an error")
+	}
+}
+
+func TestPairwiseDistances(t *testing.T) {
+	a := newTS()
+	a.Add(1, 2, 3)
+	b := newTS()
+	b.Add(2, 3, 4)
+	c := newTS()
+	c.Add(5)
+
+	want := [][]int{
+		{0, 2, 4},
+		{2, 0, 4},
+		{4, 4, 0},
+	}
+	if got := PairwiseDistances([]*Set{a, b, c}); !reflect.DeepEqual(got, want) {
+		t.Errorf("PairwiseDistances: got %v, want %v", got, want)
+	}
+}
+
+func TestSet_StringQuoted(t *testing.T) {
+	s := newTS()
+	s.Add("a, b", "c\nd", 3)
+
+	if got, want := s.StringQuoted(), `[3, "a, b", "c\nd"]`; got != want {
+		t.Errorf("StringQuoted: got %s, want %s", got, want)
+	}
+}
+
+func TestMergePrioritized(t *testing.T) {
+	a := newTS()
+	a.Add("a1", "a2", "a3")
+	b := newNonTS()
+	b.Add("a1", "b1", "b2")
+	c := newTS()
+	c.Add("c1")
+
+	u := MergePrioritized(4, a, nil, b, c)
+	if u.Size() != 4 || !u.Has("a1", "a2", "a3", "b1") {
+		t.Errorf("MergePrioritized: got %s, want [a1, a2, a3, b1]", u)
+	}
+
+	if u := MergePrioritized(10, a, b, c); u.Size() != 6 {
+		t.Errorf("MergePrioritized: under the cap all items should be merged, got %s", u)
+	}
+}
+
+func TestSet_EnvValue(t *testing.T) {
+	sep := string(os.PathListSeparator)
+
+	s := FromEnvValue("/usr/bin" + sep + "/bin" + sep + sep + "/usr/bin" + sep + "/sbin")
+	if s.Size() != 3 || !s.Has("/usr/bin", "/bin", "/sbin") {
+		t.Errorf("FromEnvValue: got %s, want [/bin, /sbin, /usr/bin]", s)
+	}
+
+	s.Add("", 1)
+	if got, want := s.EnvValue(), "/bin"+sep+"/sbin"+sep+"/usr/bin"; got != want {
+		t.Errorf("EnvValue: got %q, want %q", got, want)
+	}
+
+	if !FromEnvValue(s.EnvValue()).IsEqual(FromEnvValue("/bin" + sep + "/sbin" + sep + "/usr/bin")) {
+		t.Error("EnvValue: should round trip through FromEnvValue")
+	}
+}
+
+func TestSet_DifferenceChanged(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3")
+	u := newNonTS()
+	u.Add("4", "5")
+
+	d, changed := s.DifferenceChanged(u)
+	if changed || d != s {
+		t.Error("DifferenceChanged: disjoint sets should not change s")
+	}
+
+	u.Add("3")
+	d, changed = s.DifferenceChanged(u)
+	if !changed || d == s || !d.IsEqual(Difference(s, u)) {
+		t.Errorf("DifferenceChanged: got %s, %v, want %s, true", d, changed, Difference(s, u))
+	}
+}
+
+func TestSet_SingleDiff(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2, 3)
+
+	tests := []struct {
+		name      string
+		items     []interface{}
+		item      interface{}
+		direction int
+		ok        bool
+	}{
+		{"one extra", []interface{}{1, 2}, 3, 1, true},
+		{"one missing", []interface{}{1, 2, 3, 4}, 4, -1, true},
+		{"equal", []interface{}{1, 2, 3}, nil, 0, false},
+		{"two differences", []interface{}{1, 2, 4}, nil, 0, false},
+		{"two missing", []interface{}{1, 2, 3, 4, 5}, nil, 0, false},
+		{"extra and missing", []interface{}{1, 4}, nil, 0, false},
+		{"replaced", []interface{}{1, 5, 6, 7}, nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		u := newNonTS()
+		u.Add(tt.items...)
+
+		item, direction, ok := s.SingleDiff(u)
+		if item != tt.item || direction != tt.direction || ok != tt.ok {
+			t.Errorf("SingleDiff: %s: got (%v, %d, %v), want (%v, %d, %v)",
+				tt.name, item, direction, ok, tt.item, tt.direction, tt.ok)
+		}
+	}
+
+	if _, _, ok := s.SingleDiff(s); ok {
+		t.Error("SingleDiff: a set should not differ from itself")
+	}
+}
+
+func TestSet_Update(t *testing.T) {
+	s := newTS()
+
+	// every goroutine adds an item and trims s to the 10 largest items if it
+	// grew larger than 10
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Update(func(members []interface{}) (add, remove []interface{}) {
+				members = append(members, i)
+				if len(members) <= 10 {
+					return []interface{}{i}, nil
+				}
+				sortItems(members)
+				return []interface{}{i}, members[:len(members)-10]
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	if s.Size() != 10 {
+		t.Errorf("Update: the set should be trimmed to 10 items, got %d", s.Size())
+	}
+
+	for i := 90; i < 100; i++ {
+		if !s.Has(i) {
+			t.Errorf("Update: the largest item %d should be kept", i)
+		}
+	}
+}
+
+func TestSet_MissingOf(t *testing.T) {
+	s := newTS()
+	s.Add("a", "c", 1)
+
+	missing := s.MissingOf("d", "a", "b", 1, "1", "c")
+	if !reflect.DeepEqual(missing, []interface{}{"d", "b", "1"}) {
+		t.Errorf("MissingOf: got %v, want [d b 1] in the order they were passed", missing)
+	}
+
+	if missing := s.MissingOf("a", "c"); len(missing) != 0 {
+		t.Errorf("MissingOf: no items should be missing, got %v", missing)
+	}
+
+	if missing := s.MissingOf(); missing == nil || len(missing) != 0 {
+		t.Errorf("MissingOf: should return an empty slice for no items, got %#v", missing)
+	}
+}
+
+func TestSet_IntRanges(t *testing.T) {
+	s := newTS()
+	s.Add(9, 1, 3, 2, 6, 5, 12, "4", 4.0)
+
+	want := [][2]int{{1, 3}, {5, 6}, {9, 9}, {12, 12}}
+	if got := s.IntRanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("IntRanges: got %v, want %v", got, want)
+	}
+
+	if got := newTS().IntRanges(); got == nil || len(got) != 0 {
+		t.Errorf("IntRanges: should return an empty slice for an empty set, got %#v", got)
+	}
+}
+
+func TestSet_MatchRegexp(t *testing.T) {
+	s := newTS()
+	s.Add("main.go", "set.go", "README.md", "go", 1, 'g')
+
+	want := newNonTS()
+	want.Add("main.go", "set.go")
+
+	u := s.MatchRegexp(regexp.MustCompile(`\.go$`))
+	if !u.IsEqual(want) {
+		t.Errorf("MatchRegexp: got %s, want [main.go, set.go]", u)
+	}
+
+	if u := s.MatchRegexp(regexp.MustCompile(`^$`)); !u.IsEmpty() {
+		t.Errorf("MatchRegexp: no items should match, got %s", u)
+	}
+}
+
+func TestSet_Shards(t *testing.T) {
+	s := newTS()
+	for i := 0; i < 1000; i++ {
+		s.Add(i, strconv.Itoa(i))
+	}
+
+	shards := s.Shards(4)
+	if len(shards) != 4 {
+		t.Fatalf("Shards: got %d shards, want 4", len(shards))
+	}
+
+	var (
+		mu      sync.Mutex
+		visited = make(map[interface{}]int)
+		wg      sync.WaitGroup
+	)
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard func(func(interface{}) bool)) {
+			defer wg.Done()
+			shard(func(item interface{}) bool {
+				mu.Lock()
+				visited[item]++
+				mu.Unlock()
+				return true
+			})
+		}(shard)
+	}
+
+	// changes after taking the shards are not visible in them
+	s.Add("new")
+	wg.Wait()
+
+	if len(visited) != 2000 {
+		t.Errorf("Shards: got %d items in all shards, want 2000", len(visited))
+	}
+	for item, n := range visited {
+		if n != 1 || !s.Has(item) {
+			t.Errorf("Shards: item %v visited %d times", item, n)
+		}
+	}
+
+	again := s.Shards(4)
+	for i := range again {
+		shards[i](func(item interface{}) bool {
+			found := false
+			again[i](func(other interface{}) bool {
+				found = item == other
+				return !found
+			})
+			if !found {
+				t.Errorf("Shards: item %v should always be in shard %d", item, i)
+			}
+			return found
+		})
+	}
+}
+
+func TestSet_IsCloseWithin(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2, 3, 4)
+
+	tests := []struct {
+		name  string
+		items []interface{}
+		k     int
+		want  bool
+	}{
+		{"equal", []interface{}{1, 2, 3, 4}, 0, true},
+		{"under k", []interface{}{1, 2, 3}, 2, true},
+		{"exactly k", []interface{}{1, 2, 3, 5}, 2, true},
+		{"over k", []interface{}{1, 2, 5, 6}, 3, false},
+		{"over k by size", []interface{}{1}, 2, false},
+		{"over k by size of t", []interface{}{1, 2, 3, 4, 5, 6, 7}, 2, false},
+		{"disjoint", []interface{}{5, 6, 7, 8}, 8, true},
+		{"disjoint over k", []interface{}{5, 6, 7, 8}, 7, false},
+		{"negative k", []interface{}{1, 2, 3, 4}, -1, false},
+	}
+
+	for _, tt := range tests {
+		u := newNonTS()
+		u.Add(tt.items...)
+
+		if got := s.IsCloseWithin(u, tt.k); got != tt.want {
+			t.Errorf("IsCloseWithin: %s: got %v, want %v", tt.name, got, tt.want)
+		}
+
+		want := SymmetricDifference(s, u).Size() <= tt.k
+		if got := s.IsCloseWithin(u, tt.k); got != want {
+			t.Errorf("IsCloseWithin: %s: got %v, the symmetric difference gives %v", tt.name, got, want)
+		}
+	}
+
+	if !s.IsCloseWithin(s, 0) {
+		t.Error("IsCloseWithin: a set should be close to itself")
+	}
+}
+
+func TestSet_OnAddOnRemove(t *testing.T) {
+	s := newTS()
+	s.Add("a")
+
+	var added, removed []interface{}
+	s.OnAdd(func(item interface{}) {
+		added = append(added, item)
+		s.Has(item) // callbacks run without holding the lock
+	})
+	s.OnRemove(func(item interface{}) {
+		removed = append(removed, item)
+	})
+
+	s.Add("a", "b", "b")
+	if !reflect.DeepEqual(added, []interface{}{"b"}) {
+		t.Errorf("OnAdd: should only fire for new items, got %v", added)
+	}
+
+	u := newNonTS()
+	u.Add("b", "c")
+	s.Merge(u)
+	if !reflect.DeepEqual(added, []interface{}{"b", "c"}) {
+		t.Errorf("OnAdd: Merge should fire for new items, got %v", added)
+	}
+
+	s.Remove("x", "a")
+	if !reflect.DeepEqual(removed, []interface{}{"a"}) {
+		t.Errorf("OnRemove: should only fire for existing items, got %v", removed)
+	}
+
+	s.Separate(u)
+	if len(removed) != 3 || !s.IsEmpty() {
+		t.Errorf("OnRemove: Separate should fire for removed items, got %v", removed)
+	}
+
+	s.Add(1, 2)
+	if item := s.Pop(); removed[len(removed)-1] != item {
+		t.Errorf("OnRemove: Pop should fire for the popped item %v, got %v", item, removed)
+	}
+	s.Pop()
+	if n := len(removed); s.Pop() != nil || n != 5 {
+		t.Errorf("OnRemove: Pop of an empty set should not fire, got %v", removed)
+	}
+
+	s.Add(3)
+	s.Clear()
+	if removed[len(removed)-1] != 3 {
+		t.Errorf("OnRemove: Clear should fire for the removed items, got %v", removed)
+	}
+}
+
+func TestSet_PrecisionRecall(t *testing.T) {
+	s := newTS()
+	s.Add(1, 2, 3, 4)
+	ref := newNonTS()
+	ref.Add(3, 4, 5, 6, 7, 8, 9, 10)
+
+	tests := []struct {
+		name              string
+		s, ref            Interface
+		precision, recall float64
+	}{
+		{"overlap", s, ref, 0.5, 0.25},
+		{"same", s, s, 1, 1},
+		{"empty reference", s, newTS(), 0, 1},
+		{"empty s", newTS(), ref, 1, 0},
+		{"both empty", newTS(), newTS(), 1, 1},
+	}
+
+	for _, tt := range tests {
+		precision, recall := tt.s.(*Set).PrecisionRecall(tt.ref)
+		if precision != tt.precision || recall != tt.recall {
+			t.Errorf("PrecisionRecall: %s: got (%v, %v), want (%v, %v)",
+				tt.name, precision, recall, tt.precision, tt.recall)
+		}
+	}
+
+	whileWriting(s, func() {
+		for i := 0; i < 1000; i++ {
+			s.PrecisionRecall(s)
+		}
+	})
+}
+
+func TestSet_StableVolatile(t *testing.T) {
+	s := newTS()
+	s.Add("a", "b", "c", "d")
+	previous := newNonTS()
+	previous.Add("c", "d", "e")
+
+	stable, volatile := s.StableVolatile(previous)
+
+	if !stable.IsEqual(Intersection(s, previous)) {
+		t.Errorf("StableVolatile: stable should be [c, d], got %s", stable)
+	}
+	if !volatile.IsEqual(SymmetricDifference(s, previous)) {
+		t.Errorf("StableVolatile: volatile should be [a, b, e], got %s", volatile)
+	}
+	if !Union(stable, volatile).IsEqual(Union(s, previous)) {
+		t.Error("StableVolatile: the union of the partitions should be the union of the sets")
+	}
+	if !PairwiseDisjoint(stable, volatile) {
+		t.Error("StableVolatile: the partitions should be disjoint")
+	}
+
+	stable, volatile = s.StableVolatile(s)
+	if !stable.IsEqual(s) || !volatile.IsEmpty() {
+		t.Error("StableVolatile: all items of a set should be stable against itself")
+	}
+}
+
+func TestSet_Filter(t *testing.T) {
+	s := newTS()
+	s.Add("a", "abcd", "abc", "abcde", 5)
+
+	long := func(item interface{}) bool {
+		v, ok := item.(string)
+		return ok && len(v) > 3
+	}
+
+	u := s.Filter(long)
+	if u.Size() != 2 || !u.Has("abcd", "abcde") {
+		t.Errorf("Filter: got %s, want [abcd, abcde]", u)
+	}
+	if s.Size() != 5 {
+		t.Error("Filter: should not modify the set")
+	}
+
+	if none := s.Filter(func(item interface{}) bool { return false }); none != nil {
+		t.Errorf("Filter: should return nil if nothing matches, got %s", none)
+	}
+
+	var nilSet *Set
+	if nilSet.Filter(long) != nil {
+		t.Error("Filter: should return nil for a nil set")
+	}
+}
+
+func TestSet_Map(t *testing.T) {
+	s := newTS()
+	s.Add("a", "A", "b")
+
+	u := s.Map(func(item interface{}) interface{} {
+		return strings.ToLower(item.(string))
+	})
+	if u.Size() != 2 || !u.Has("a", "b") {
+		t.Errorf("Map: got %s, want [a, b]", u)
+	}
+	if s.Size() != 3 || !s.Has("A") {
+		t.Error("Map: should not modify the set")
+	}
+
+	var nilSet *Set
+	if nilSet.Map(func(item interface{}) interface{} { return item }) != nil {
+		t.Error("Map: should return nil for a nil set")
+	}
+}
+
+func TestSet_OnAddOnRemove_allMethods(t *testing.T) {
+	other := func(items ...interface{}) Interface {
+		u := newNonTS()
+		u.Add(items...)
+		return u
+	}
+	one := func(item interface{}) bool { return item == 1 }
+
+	// every method that modifies a set, starting with the items 1, 2 and 3
+	mutators := map[string]func(s *Set){
+		"Add":                 func(s *Set) { s.Add(3, 4) },
+		"AddSafe":             func(s *Set) { s.AddSafe(3, 4) },
+		"AddIfUnderLimit":     func(s *Set) { s.AddIfUnderLimit(4, 10) },
+		"AddReportDuplicates": func(s *Set) { s.AddReportDuplicates(3, 4) },
+		"AddLines":            func(s *Set) { s.AddLines(strings.NewReader("a\nb\n")) },
+		"Merge":               func(s *Set) { s.Merge(other(3, 4)) },
+		"MergeAll":            func(s *Set) { s.MergeAll(other(3, 4), other(5)) },
+		"MergeCapped":         func(s *Set) { s.MergeCapped(other(4, 5, 6), 5) },
+		"Remove":              func(s *Set) { s.Remove(1, 4) },
+		"Pop":                 func(s *Set) { s.Pop() },
+		"Clear":               func(s *Set) { s.Clear() },
+		"Separate":            func(s *Set) { s.Separate(other(1, 4)) },
+		"Drain":               func(s *Set) { s.Drain(func(interface{}) {}) },
+		"Replace":             func(s *Set) { s.Replace(1, 4) },
+		"RemoveFunc":          func(s *Set) { s.RemoveFunc(one) },
+		"RetainFunc":          func(s *Set) { s.RetainFunc(one) },
+		"EachRemove":          func(s *Set) { s.EachRemove(one) },
+		"SubtractReport":      func(s *Set) { s.SubtractReport(other(1, 4)) },
+		"RemoveIntersection":  func(s *Set) { s.RemoveIntersection(other(1, 4)) },
+		"RetainAll":           func(s *Set) { s.RetainAll(other(1, 4)) },
+		"ApplyPatch":          func(s *Set) { s.ApplyPatch(s.Patch(other(1, 4))) },
+		"Swap":                func(s *Set) { s.Swap(other(1, 4)) },
+		"UnmarshalJSON":       func(s *Set) { s.UnmarshalJSON([]byte(`[1, 4]`)) },
+		"UnmarshalJSONTyped":  func(s *Set) { s.UnmarshalJSONTyped([]byte(`[3, 4]`), reflect.TypeOf(0)) },
+		"Update": func(s *Set) {
+			s.Update(func([]interface{}) (add, remove []interface{}) {
+				return []interface{}{4}, []interface{}{1, 5}
+			})
+		},
+		"Builder": func(s *Set) {
+			b := s.Builder()
+			b.Add(4)
+			b.Remove(1, 5)
+			b.Commit()
+		},
+	}
+
+	for name, mutate := range mutators {
+		s := newTS()
+		s.Add(1, 2, 3)
+		before := s.Copy()
+
+		added, removed := newNonTS(), newNonTS()
+		s.OnAdd(func(item interface{}) {
+			if added.Has(item) {
+				t.Errorf("%s: OnAdd called twice for %v", name, item)
+			}
+			added.Add(item)
+		})
+		s.OnRemove(func(item interface{}) {
+			if removed.Has(item) {
+				t.Errorf("%s: OnRemove called twice for %v", name, item)
+			}
+			removed.Add(item)
+		})
+
+		mutate(s)
+
+		if want := Difference(s, before); !added.IsEqual(want) {
+			t.Errorf("%s: OnAdd called for %s, want %s", name, added, want)
+		}
+		if want := Difference(before, s); !removed.IsEqual(want) {
+			t.Errorf("%s: OnRemove called for %s, want %s", name, removed, want)
+		}
+		if removed.IsEmpty() && added.IsEmpty() {
+			t.Errorf("%s: should modify the set", name)
+		}
+	}
+}
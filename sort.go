@@ -0,0 +1,176 @@
+package set
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// DefaultLess is the order used by all methods that return items in a
+// deterministic order, such as SortedList(), String() and Page(). It must
+// define a strict total order over all items that are stored in sets.
+//
+// It's not protected by a lock; if it's replaced, it must be done before any
+// set is used, e.g. in an init function.
+var DefaultLess = KindLess
+
+// KindLess is the default value of DefaultLess. Items are ordered by kind
+// first. Numbers, strings and bools of the same kind are compared by value,
+// with NaN before any other float, anything else by its type name and then its
+// fmt representation.
+func KindLess(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if ka, kb := va.Kind(), vb.Kind(); ka != kb {
+		return ka < kb
+	}
+
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if x, y := va.Int(), vb.Int(); x != y {
+			return x < y
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if x, y := va.Uint(), vb.Uint(); x != y {
+			return x < y
+		}
+	case reflect.Float32, reflect.Float64:
+		x, y := va.Float(), vb.Float()
+		if nx, ny := math.IsNaN(x), math.IsNaN(y); nx || ny {
+			// NaN isn't ordered by <, it comes before any other number
+			if nx != ny {
+				return nx
+			}
+		} else if x != y {
+			return x < y
+		}
+	case reflect.String:
+		if x, y := va.String(), vb.String(); x != y {
+			return x < y
+		}
+	case reflect.Bool:
+		if x, y := va.Bool(), vb.Bool(); x != y {
+			return !x
+		}
+	case reflect.Invalid:
+		return false // both are nil
+	}
+
+	// same value but different types, e.g. int32(1) and int64(1)
+	if ta, tb := va.Type().String(), vb.Type().String(); ta != tb {
+		return ta < tb
+	}
+
+	return fmt.Sprintf("%#v", a) < fmt.Sprintf("%#v", b)
+}
+
+// sortItems sorts items in place in the order defined by DefaultLess.
+func sortItems(items []interface{}) {
+	less := DefaultLess
+	sort.Slice(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+}
+
+// scoredItem is an item with the score it was ranked by.
+type scoredItem struct {
+	item  interface{}
+	score float64
+}
+
+// worse reports whether a ranks below b: it has a lower score or, on equal
+// scores, comes later in the order defined by DefaultLess.
+func (a scoredItem) worse(b scoredItem) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return DefaultLess(b.item, a.item)
+}
+
+// scoredHeap is a min-heap of scored items with the worst item at the root.
+type scoredHeap []scoredItem
+
+func (h scoredHeap) Len() int            { return len(h) }
+func (h scoredHeap) Less(i, j int) bool  { return h[i].worse(h[j]) }
+func (h scoredHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *scoredHeap) Push(x interface{}) { *h = append(*h, x.(scoredItem)) }
+func (h *scoredHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// SortedSliceUnion returns the sorted union of a and b, which must be sorted
+// by less. It merges the slices in a single pass without building sets.
+// Items x and y are equal if neither less(x, y) nor less(y, x); duplicates are
+// only returned once.
+func SortedSliceUnion(a, b []interface{}, less func(x, y interface{}) bool) []interface{} {
+	result := make([]interface{}, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		var item interface{}
+		switch {
+		case j == len(b) || (i < len(a) && less(a[i], b[j])):
+			item = a[i]
+			i++
+		case i == len(a) || less(b[j], a[i]):
+			item = b[j]
+			j++
+		default:
+			item = a[i]
+			i++
+			j++
+		}
+		result = appendUnique(result, item, less)
+	}
+	return result
+}
+
+// SortedSliceIntersection returns the sorted intersection of a and b, which
+// must be sorted by less. See SortedSliceUnion for details.
+func SortedSliceIntersection(a, b []interface{}, less func(x, y interface{}) bool) []interface{} {
+	result := make([]interface{}, 0)
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case less(a[i], b[j]):
+			i++
+		case less(b[j], a[i]):
+			j++
+		default:
+			result = appendUnique(result, a[i], less)
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// SortedSliceDifference returns the sorted items of a that are not in b, which
+// must both be sorted by less. See SortedSliceUnion for details.
+func SortedSliceDifference(a, b []interface{}, less func(x, y interface{}) bool) []interface{} {
+	result := make([]interface{}, 0, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		switch {
+		case j == len(b) || less(a[i], b[j]):
+			result = appendUnique(result, a[i], less)
+			i++
+		case less(b[j], a[i]):
+			j++
+		default:
+			i++ // in both, but b[j] may match following duplicates in a
+		}
+	}
+	return result
+}
+
+// appendUnique appends item to the sorted slice s unless it equals the last
+// item of s.
+func appendUnique(s []interface{}, item interface{}, less func(x, y interface{}) bool) []interface{} {
+	if n := len(s); n > 0 && !less(s[n-1], item) && !less(item, s[n-1]) {
+		return s
+	}
+	return append(s, item)
+}
@@ -0,0 +1,90 @@
+package set
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestKindLess(t *testing.T) {
+	s := newTS()
+	s.Add("b", 10, "a", 2, int64(2), 1.5, true, false)
+
+	want := []interface{}{false, true, 2, 10, int64(2), 1.5, "a", "b"}
+	for i := 0; i < 10; i++ {
+		if got := s.SortedList(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("SortedList: got %v, want %v", got, want)
+		}
+	}
+
+	if got := s.String(); got != "[false, true, 2, 10, 2, 1.5, a, b]" {
+		t.Errorf("String: got %s", got)
+	}
+
+	// NaN is never equal to itself, so a set can hold it more than once
+	u := newTS()
+	u.Add(math.NaN(), 1.0, math.NaN(), 2.0, 0.5, float32(math.NaN()))
+	for i := 0; i < 10; i++ {
+		if got := u.String(); got != "[NaN, NaN, NaN, 0.5, 1, 2]" {
+			t.Fatalf("String: NaN should come before other floats, got %s", got)
+		}
+	}
+}
+
+func TestDefaultLess(t *testing.T) {
+	defer func(less func(a, b interface{}) bool) { DefaultLess = less }(DefaultLess)
+
+	// strings by length, everything else after them
+	DefaultLess = func(a, b interface{}) bool {
+		x, ok := a.(string)
+		if !ok {
+			return false
+		}
+		y, ok := b.(string)
+		if !ok {
+			return true
+		}
+		if len(x) != len(y) {
+			return len(x) < len(y)
+		}
+		return x < y
+	}
+
+	s := newTS()
+	s.Add("ccc", "a", "bb")
+
+	if got := s.Page(0, 3); !reflect.DeepEqual(got, []interface{}{"a", "bb", "ccc"}) {
+		t.Errorf("Page: should use DefaultLess, got %v", got)
+	}
+}
+
+func TestSortedSlice(t *testing.T) {
+	a := []interface{}{1, 2, 2, 4, 6, 8}
+	b := []interface{}{2, 3, 4, 4, 5, 9}
+
+	s := newTS()
+	s.Add(a...)
+	u := newTS()
+	u.Add(b...)
+
+	tests := []struct {
+		name string
+		got  []interface{}
+		want Interface
+	}{
+		{"SortedSliceUnion", SortedSliceUnion(a, b, KindLess), Union(s, u)},
+		{"SortedSliceIntersection", SortedSliceIntersection(a, b, KindLess), Intersection(s, u)},
+		{"SortedSliceDifference", SortedSliceDifference(a, b, KindLess), Difference(s, u)},
+		{"SortedSliceDifference", SortedSliceDifference(b, a, KindLess), Difference(u, s)},
+	}
+
+	for _, tt := range tests {
+		if want := tt.want.(*Set).SortedList(); !reflect.DeepEqual(tt.got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, want)
+		}
+	}
+
+	if got := SortedSliceUnion(nil, nil, KindLess); len(got) != 0 {
+		t.Errorf("SortedSliceUnion: empty slices should give an empty result, got %v", got)
+	}
+}
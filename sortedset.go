@@ -0,0 +1,162 @@
+package set
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// SortedSet defines a thread safe set of ordered values, such as ints or
+// strings, that keeps its items sorted. Unlike Set it can answer range and
+// neighbor queries like Floor() and Range(). The items are stored in a sorted
+// slice, so lookups take logarithmic time, and adding or removing an item
+// takes linear time.
+type SortedSet[T cmp.Ordered] struct {
+	items []T
+	l     sync.RWMutex
+}
+
+// NewSorted creates and initializes a new SortedSet with the given items.
+func NewSorted[T cmp.Ordered](items ...T) *SortedSet[T] {
+	s := &SortedSet[T]{}
+	s.Add(items...)
+	return s
+}
+
+// Add includes the specified items (one or more) to the set. Items that
+// already exist are ignored.
+func (s *SortedSet[T]) Add(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		i, found := slices.BinarySearch(s.items, item)
+		if !found {
+			s.items = slices.Insert(s.items, i, item)
+		}
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *SortedSet[T]) Remove(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		i, found := slices.BinarySearch(s.items, item)
+		if found {
+			s.items = slices.Delete(s.items, i, i+1)
+		}
+	}
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *SortedSet[T]) Has(items ...T) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for _, item := range items {
+		if _, found := slices.BinarySearch(s.items, item); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in the set.
+func (s *SortedSet[T]) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.items)
+}
+
+// List returns all items in ascending order.
+func (s *SortedSet[T]) List() []T {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return slices.Clone(s.items)
+}
+
+// Min returns the smallest item. ok is false if the set is empty.
+func (s *SortedSet[T]) Min() (item T, ok bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.items) == 0 {
+		return item, false
+	}
+	return s.items[0], true
+}
+
+// Max returns the largest item. ok is false if the set is empty.
+func (s *SortedSet[T]) Max() (item T, ok bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.items) == 0 {
+		return item, false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+// Ceiling returns the smallest item greater than or equal to x. ok is false if
+// there is no such item.
+func (s *SortedSet[T]) Ceiling(x T) (item T, ok bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	i, _ := slices.BinarySearch(s.items, x)
+	if i == len(s.items) {
+		return item, false
+	}
+	return s.items[i], true
+}
+
+// Floor returns the largest item less than or equal to x. ok is false if there
+// is no such item.
+func (s *SortedSet[T]) Floor(x T) (item T, ok bool) {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	i, found := slices.BinarySearch(s.items, x)
+	if found {
+		return s.items[i], true
+	}
+	if i == 0 {
+		return item, false
+	}
+	return s.items[i-1], true
+}
+
+// Range returns the items between lo and hi, both inclusive, in ascending
+// order. It returns an empty slice if lo is greater than hi.
+func (s *SortedSet[T]) Range(lo, hi T) []T {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if cmp.Less(hi, lo) {
+		return []T{}
+	}
+
+	i, _ := slices.BinarySearch(s.items, lo)
+	j, found := slices.BinarySearch(s.items, hi)
+	if found {
+		j++
+	}
+	return slices.Clone(s.items[i:j])
+}
@@ -0,0 +1,97 @@
+package set
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortedSet(t *testing.T) {
+	s := NewSorted(5, 1, 9, 3, 5, 7, 1)
+
+	if s.Size() != 5 {
+		t.Errorf("SortedSet: duplicates should be added once, got size %d", s.Size())
+	}
+
+	if list := s.List(); !reflect.DeepEqual(list, []int{1, 3, 5, 7, 9}) {
+		t.Errorf("SortedSet: List should be sorted, got %v", list)
+	}
+
+	if !s.Has(1, 9) || s.Has(1, 2) || s.Has() {
+		t.Error("SortedSet: Has returned a wrong result")
+	}
+
+	s.Remove(7, 8)
+	if s.Has(7) || s.Size() != 4 {
+		t.Error("SortedSet: Remove should delete the existing items")
+	}
+}
+
+func TestSortedSet_MinMax(t *testing.T) {
+	s := NewSorted[string]()
+
+	if _, ok := s.Min(); ok {
+		t.Error("Min: an empty set should have no minimum")
+	}
+	if _, ok := s.Max(); ok {
+		t.Error("Max: an empty set should have no maximum")
+	}
+
+	s.Add("b", "c", "a", "c")
+	if min, ok := s.Min(); !ok || min != "a" {
+		t.Errorf("Min: got %q, %v", min, ok)
+	}
+	if max, ok := s.Max(); !ok || max != "c" {
+		t.Errorf("Max: got %q, %v", max, ok)
+	}
+}
+
+func TestSortedSet_CeilingFloor(t *testing.T) {
+	s := NewSorted(10, 20, 30, 20)
+
+	tests := []struct {
+		x         int
+		ceiling   int
+		ceilingOk bool
+		floor     int
+		floorOk   bool
+	}{
+		{5, 10, true, 0, false},
+		{10, 10, true, 10, true},
+		{15, 20, true, 10, true},
+		{20, 20, true, 20, true},
+		{30, 30, true, 30, true},
+		{35, 0, false, 30, true},
+	}
+
+	for _, tt := range tests {
+		if got, ok := s.Ceiling(tt.x); got != tt.ceiling || ok != tt.ceilingOk {
+			t.Errorf("Ceiling(%d): got (%d, %v), want (%d, %v)", tt.x, got, ok, tt.ceiling, tt.ceilingOk)
+		}
+		if got, ok := s.Floor(tt.x); got != tt.floor || ok != tt.floorOk {
+			t.Errorf("Floor(%d): got (%d, %v), want (%d, %v)", tt.x, got, ok, tt.floor, tt.floorOk)
+		}
+	}
+}
+
+func TestSortedSet_Range(t *testing.T) {
+	s := NewSorted(1, 3, 5, 7, 9, 3, 7)
+
+	tests := []struct {
+		lo, hi int
+		want   []int
+	}{
+		{3, 7, []int{3, 5, 7}},
+		{2, 8, []int{3, 5, 7}},
+		{0, 100, []int{1, 3, 5, 7, 9}},
+		{5, 5, []int{5}},
+		{4, 4, []int{}},
+		{10, 20, []int{}},
+		{7, 3, []int{}},
+	}
+
+	for _, tt := range tests {
+		if got := s.Range(tt.lo, tt.hi); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Range(%d, %d): got %v, want %v", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+}
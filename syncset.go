@@ -0,0 +1,69 @@
+package set
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SyncSet defines a thread safe set backed by a sync.Map instead of a map and
+// a lock. It's faster than Set for read-heavy use across many goroutines when
+// items are rarely added or removed, as lookups don't contend on a lock.
+type SyncSet struct {
+	m    sync.Map
+	size atomic.Int64
+}
+
+// NewSyncSet creates and initializes a new SyncSet.
+func NewSyncSet() *SyncSet {
+	return &SyncSet{}
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *SyncSet) Add(items ...interface{}) {
+	for _, item := range items {
+		if _, loaded := s.m.LoadOrStore(item, keyExists); !loaded {
+			s.size.Add(1)
+		}
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *SyncSet) Remove(items ...interface{}) {
+	for _, item := range items {
+		if _, loaded := s.m.LoadAndDelete(item); loaded {
+			s.size.Add(-1)
+		}
+	}
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *SyncSet) Has(items ...interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	for _, item := range items {
+		if _, ok := s.m.Load(item); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in the set. The count is updated right
+// after an item is stored or deleted, not atomically with it, so while Add or
+// Remove calls are in flight it's approximate. It's never negative, even if a
+// Remove of an item is counted before the Add that stored it.
+func (s *SyncSet) Size() int {
+	return max(int(s.size.Load()), 0)
+}
+
+// Range calls f for each item in the set until f returns false. Like
+// sync.Map.Range, it doesn't see a consistent snapshot if the set is modified
+// concurrently.
+func (s *SyncSet) Range(f func(item interface{}) bool) {
+	s.m.Range(func(key, _ interface{}) bool {
+		return f(key)
+	})
+}
@@ -0,0 +1,87 @@
+package set
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSyncSet(t *testing.T) {
+	s := NewSyncSet()
+	s.Add("1", "2", "2", 3)
+
+	if s.Size() != 3 || !s.Has("1", "2", 3) {
+		t.Error("SyncSet: added items should exist")
+	}
+
+	s.Remove("1", "4")
+	if s.Size() != 2 || s.Has("1") {
+		t.Error("SyncSet: removed items should not exist")
+	}
+
+	n := 0
+	s.Range(func(item interface{}) bool {
+		n++
+		return true
+	})
+	if n != 2 {
+		t.Errorf("Range: should visit two items, got %d", n)
+	}
+}
+
+func TestSyncSet_concurrent(t *testing.T) {
+	s := NewSyncSet()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				s.Add(j)
+				s.Remove(j / 2)
+			}
+		}()
+	}
+	wg.Wait()
+
+	n := 0
+	s.Range(func(item interface{}) bool {
+		n++
+		return true
+	})
+	if n != s.Size() {
+		t.Errorf("SyncSet: size %d should match the number of items %d", s.Size(), n)
+	}
+}
+
+func BenchmarkSetContended(b *testing.B) {
+	s := newTS()
+	for i := 0; i < 1000; i++ {
+		s.Add(i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Has(i % 1000)
+			i++
+		}
+	})
+}
+
+func BenchmarkSyncSetContended(b *testing.B) {
+	s := NewSyncSet()
+	for i := 0; i < 1000; i++ {
+		s.Add(i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Has(i % 1000)
+			i++
+		}
+	})
+}
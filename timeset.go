@@ -0,0 +1,69 @@
+package set
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeSet defines a thread safe set of time.Time values. Times are compared by
+// the instant they represent: the monotonic clock reading is stripped and the
+// location is set to UTC before they are stored or looked up. Without that
+// time.Now() and time.Now().UTC() would be different items.
+type TimeSet struct {
+	s *Set
+}
+
+// NewTimeSet creates and initializes a new TimeSet.
+func NewTimeSet() *TimeSet {
+	return &TimeSet{s: newTS()}
+}
+
+// normalizeTime returns the form t is stored in.
+func normalizeTime(t time.Time) time.Time {
+	return t.Round(0).UTC()
+}
+
+// Add includes the specified times (one or more) to the set.
+func (s *TimeSet) Add(times ...time.Time) {
+	s.s.Add(timeItems(times)...)
+}
+
+// Remove deletes the specified times from the set.
+func (s *TimeSet) Remove(times ...time.Time) {
+	s.s.Remove(timeItems(times)...)
+}
+
+// Has looks for the existence of the times passed. It returns false if nothing
+// is passed. For multiple times it returns true only if all of them exist.
+func (s *TimeSet) Has(times ...time.Time) bool {
+	return s.s.Has(timeItems(times)...)
+}
+
+// Size returns the number of times in the set.
+func (s *TimeSet) Size() int {
+	return s.s.Size()
+}
+
+// List returns a sorted slice of all times, in UTC.
+func (s *TimeSet) List() []time.Time {
+	list := s.s.List()
+
+	times := make([]time.Time, len(list))
+	for i, item := range list {
+		times[i] = item.(time.Time)
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	return times
+}
+
+// timeItems returns the normalized times as items of the underlying set.
+func timeItems(times []time.Time) []interface{} {
+	items := make([]interface{}, len(times))
+	for i, t := range times {
+		items[i] = normalizeTime(t)
+	}
+	return items
+}
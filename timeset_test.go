@@ -0,0 +1,34 @@
+package set
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeSet(t *testing.T) {
+	now := time.Now()
+	ist := time.FixedZone("IST", 3*60*60)
+
+	s := NewTimeSet()
+	s.Add(now)
+
+	if !s.Has(now, now.Round(0), now.UTC(), now.In(ist)) {
+		t.Error("TimeSet: times of the same instant should be the same item")
+	}
+
+	s.Add(now.UTC(), now.In(ist))
+	if s.Size() != 1 {
+		t.Errorf("TimeSet: times of the same instant should not be added twice, got size %d", s.Size())
+	}
+
+	later := now.Add(time.Second)
+	s.Add(later)
+	if list := s.List(); len(list) != 2 || !list[0].Equal(now) || !list[1].Equal(later) {
+		t.Errorf("TimeSet: List should return the sorted times, got %v", list)
+	}
+
+	s.Remove(now.In(ist))
+	if s.Has(now) || s.Size() != 1 {
+		t.Error("TimeSet: removing a time of the same instant should remove the item")
+	}
+}
@@ -0,0 +1,174 @@
+package set
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLSet defines a thread safe set whose items expire after a given duration.
+// Expired items are ignored by all methods and are deleted lazily when they
+// are looked up, or all at once by Cleanup.
+type TTLSet struct {
+	m   map[interface{}]time.Time // item -> expiry
+	now func() time.Time
+	l   sync.RWMutex
+
+	onAdd    []func(item interface{}) // see OnAdd()
+	onRemove []func(item interface{}) // see OnRemove()
+}
+
+// NewTTLSet creates and initializes a new TTLSet.
+func NewTTLSet() *TTLSet {
+	return NewTTLSetWithClock(time.Now)
+}
+
+// NewTTLSetWithClock is like NewTTLSet, but uses now to get the current time
+// instead of time.Now.
+func NewTTLSetWithClock(now func() time.Time) *TTLSet {
+	return &TTLSet{
+		m:   make(map[interface{}]time.Time),
+		now: now,
+	}
+}
+
+// Add includes item to the set until ttl has passed. Adding an existing item
+// resets its expiry.
+func (s *TTLSet) Add(item interface{}, ttl time.Duration) {
+	s.l.Lock()
+	now := s.now()
+	expiry, existed := s.m[item]
+	s.m[item] = now.Add(ttl)
+	onAdd, onRemove := s.onAdd, s.onRemove
+	s.l.Unlock()
+
+	switch {
+	case !existed:
+		fire(onAdd, []interface{}{item})
+	case !now.Before(expiry):
+		// the expired item was not deleted yet, it's replaced by a new one
+		fire(onRemove, []interface{}{item})
+		fire(onAdd, []interface{}{item})
+	}
+}
+
+// OnAdd registers fn to be called for every item that is added to s. It's
+// only called for items that were not in s before or had expired. Resetting
+// the expiry of an existing item doesn't call it.
+func (s *TTLSet) OnAdd(fn func(item interface{})) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.onAdd = append(s.onAdd, fn)
+}
+
+// OnRemove registers fn to be called for every item that is removed from s,
+// either by Remove() or when it's deleted after expiring. Expired items are
+// deleted lazily, so fn is called when they are looked up with Has(), by
+// Cleanup() or when they are added again, not when they expire. Like the
+// callbacks of Set, they are called after the lock is released.
+func (s *TTLSet) OnRemove(fn func(item interface{})) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.onRemove = append(s.onRemove, fn)
+}
+
+// Remove deletes the specified items from the set.
+func (s *TTLSet) Remove(items ...interface{}) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	var removed []interface{}
+	for _, item := range items {
+		if _, ok := s.m[item]; ok {
+			delete(s.m, item)
+			removed = append(removed, item)
+		}
+	}
+	onRemove := s.onRemove
+	s.l.Unlock()
+
+	fire(onRemove, removed)
+}
+
+// Has reports whether item is in the set and not expired. An expired item is
+// deleted.
+func (s *TTLSet) Has(item interface{}) bool {
+	s.l.RLock()
+	expiry, ok := s.m[item]
+	s.l.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	now := s.now()
+	if now.Before(expiry) {
+		return true
+	}
+
+	s.l.Lock()
+	// check again, it might have been added again in the meantime
+	expiry, ok = s.m[item]
+	deleted := ok && !now.Before(expiry)
+	if deleted {
+		delete(s.m, item)
+	}
+	onRemove := s.onRemove
+	s.l.Unlock()
+
+	if deleted {
+		fire(onRemove, []interface{}{item})
+	}
+	return false
+}
+
+// Size returns the number of items in the set that are not expired.
+func (s *TTLSet) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	now := s.now()
+	n := 0
+	for _, expiry := range s.m {
+		if now.Before(expiry) {
+			n++
+		}
+	}
+	return n
+}
+
+// List returns a slice of all items that are not expired.
+func (s *TTLSet) List() []interface{} {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	now := s.now()
+	list := make([]interface{}, 0, len(s.m))
+	for item, expiry := range s.m {
+		if now.Before(expiry) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Cleanup deletes all expired items and returns their number.
+func (s *TTLSet) Cleanup() int {
+	s.l.Lock()
+	now := s.now()
+	var removed []interface{}
+	for item, expiry := range s.m {
+		if !now.Before(expiry) {
+			delete(s.m, item)
+			removed = append(removed, item)
+		}
+	}
+	onRemove := s.onRemove
+	s.l.Unlock()
+
+	fire(onRemove, removed)
+	return len(removed)
+}
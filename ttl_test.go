@@ -0,0 +1,108 @@
+package set
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for tests that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTTLSet(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	s := NewTTLSetWithClock(clock.Now)
+
+	s.Add("a", time.Minute)
+	s.Add("b", 5*time.Minute)
+
+	if !s.Has("a") || !s.Has("b") || s.Size() != 2 {
+		t.Error("TTLSet: items should exist before they expire")
+	}
+
+	clock.Advance(time.Minute)
+	if s.Has("a") || !s.Has("b") || s.Size() != 1 {
+		t.Error("TTLSet: a should be expired")
+	}
+
+	if list := s.List(); len(list) != 1 || list[0] != "b" {
+		t.Errorf("TTLSet: List should only return b, got %v", list)
+	}
+
+	// adding again resets the expiry
+	s.Add("b", 5*time.Minute)
+	clock.Advance(4 * time.Minute)
+	if !s.Has("b") {
+		t.Error("TTLSet: adding an item again should reset its expiry")
+	}
+
+	s.Add("c", time.Minute)
+	clock.Advance(time.Hour)
+	if n := s.Cleanup(); n != 2 {
+		t.Errorf("Cleanup: should delete two expired items, got %d", n)
+	}
+
+	if s.Size() != 0 || len(s.m) != 0 {
+		t.Error("Cleanup: the set should be empty")
+	}
+}
+
+func TestTTLSet_Remove(t *testing.T) {
+	s := NewTTLSet()
+	s.Add("a", time.Hour)
+	s.Remove("a")
+
+	if s.Has("a") {
+		t.Error("Remove: removed item should not exist")
+	}
+}
+
+func TestTTLSet_OnAddOnRemove(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	s := NewTTLSetWithClock(clock.Now)
+
+	var added, removed []interface{}
+	s.OnAdd(func(item interface{}) { added = append(added, item) })
+	s.OnRemove(func(item interface{}) { removed = append(removed, item) })
+
+	s.Add("a", time.Second)
+	s.Add("a", time.Second) // only resets the expiry
+	s.Add("b", time.Second)
+	s.Add("c", 2*time.Second)
+	s.Add("d", time.Second)
+	if len(added) != 4 {
+		t.Errorf("OnAdd: should be called for new items, got %v", added)
+	}
+
+	s.Remove("x", "d")
+	if len(removed) != 1 || removed[0] != "d" {
+		t.Errorf("OnRemove: Remove should only report existing items, got %v", removed)
+	}
+
+	clock.Advance(time.Second)
+	s.Has("a")
+	s.Add("b", time.Second) // replaces the expired item
+	if len(removed) != 3 || removed[1] != "a" || removed[2] != "b" || added[4] != "b" {
+		t.Errorf("OnRemove: expired items should be reported when deleted, got %v", removed)
+	}
+
+	clock.Advance(time.Second)
+	if n := s.Cleanup(); n != 2 || len(removed) != 5 {
+		t.Errorf("OnRemove: Cleanup should report the deleted items, got %v", removed)
+	}
+}
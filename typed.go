@@ -0,0 +1,134 @@
+package set
+
+import "sync"
+
+// TypedSet defines a thread safe set of items of type T. Unlike Set it
+// doesn't need type assertions, e.g. List() returns a []T, and adding an item
+// of the wrong type doesn't compile.
+type TypedSet[T comparable] struct {
+	m map[T]struct{}
+	l sync.RWMutex
+}
+
+// NewTyped creates and initializes a new TypedSet with the given items.
+func NewTyped[T comparable](items ...T) *TypedSet[T] {
+	s := &TypedSet[T]{m: make(map[T]struct{}, len(items))}
+	for _, item := range items {
+		s.m[item] = keyExists
+	}
+	return s
+}
+
+// Add includes the specified items (one or more) to the set.
+func (s *TypedSet[T]) Add(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		s.m[item] = keyExists
+	}
+}
+
+// Remove deletes the specified items from the set.
+func (s *TypedSet[T]) Remove(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, item := range items {
+		delete(s.m, item)
+	}
+}
+
+// Has looks for the existence of items passed. It returns false if nothing is
+// passed. For multiple items it returns true only if all of the items exist.
+func (s *TypedSet[T]) Has(items ...T) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	for _, item := range items {
+		if _, ok := s.m[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the number of items in the set.
+func (s *TypedSet[T]) Size() int {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	return len(s.m)
+}
+
+// List returns a slice of all items, in no particular order.
+func (s *TypedSet[T]) List() []T {
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	list := make([]T, 0, len(s.m))
+	for item := range s.m {
+		list = append(list, item)
+	}
+	return list
+}
+
+// IsEqual test whether s and t have the same items.
+func (s *TypedSet[T]) IsEqual(t *TypedSet[T]) bool {
+	items := t.List() // t may be s, read it before locking
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	if len(s.m) != len(items) {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := s.m[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns a new set with the items that are in s or in t.
+func (s *TypedSet[T]) Union(t *TypedSet[T]) *TypedSet[T] {
+	u := NewTyped(t.List()...)
+	u.Add(s.List()...)
+	return u
+}
+
+// Intersection returns a new set with the items that are in both s and t.
+func (s *TypedSet[T]) Intersection(t *TypedSet[T]) *TypedSet[T] {
+	items := t.List()
+
+	s.l.RLock()
+	defer s.l.RUnlock()
+
+	u := NewTyped[T]()
+	for _, item := range items {
+		if _, ok := s.m[item]; ok {
+			u.m[item] = keyExists
+		}
+	}
+	return u
+}
+
+// Difference returns a new set with the items that are in s but not in t.
+func (s *TypedSet[T]) Difference(t *TypedSet[T]) *TypedSet[T] {
+	u := NewTyped(s.List()...)
+	u.Remove(t.List()...)
+	return u
+}
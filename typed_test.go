@@ -0,0 +1,55 @@
+package set
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestTypedSet(t *testing.T) {
+	s := NewTyped("a", "b", "a")
+	s.Add("c")
+
+	if s.Size() != 3 || !s.Has("a", "b", "c") || s.Has("d") || s.Has() {
+		t.Errorf("TypedSet: got %v, want [a b c]", s.List())
+	}
+
+	s.Remove("a", "x")
+	list := s.List()
+	sort.Strings(list)
+	if len(list) != 2 || list[0] != "b" || list[1] != "c" {
+		t.Errorf("TypedSet: got %v after Remove, want [b c]", list)
+	}
+}
+
+func TestTypedSet_IsEqual(t *testing.T) {
+	s := NewTyped(1, 2, 3)
+
+	if !s.IsEqual(NewTyped(3, 2, 1)) || !s.IsEqual(s) {
+		t.Error("IsEqual: sets with the same items should be equal")
+	}
+	if s.IsEqual(NewTyped(1, 2)) || s.IsEqual(NewTyped(1, 2, 4)) {
+		t.Error("IsEqual: sets with different items should not be equal")
+	}
+}
+
+func TestTypedSet_Operations(t *testing.T) {
+	s := NewTyped(1, 2, 3, 4)
+	u := NewTyped(3, 4, 5)
+
+	if got := s.Union(u); !got.IsEqual(NewTyped(1, 2, 3, 4, 5)) {
+		t.Errorf("Union: got %v", got.List())
+	}
+	if got := s.Intersection(u); !got.IsEqual(NewTyped(3, 4)) {
+		t.Errorf("Intersection: got %v", got.List())
+	}
+	if got := s.Difference(u); !got.IsEqual(NewTyped(1, 2)) {
+		t.Errorf("Difference: got %v", got.List())
+	}
+	if got := s.Difference(s); got.Size() != 0 {
+		t.Errorf("Difference: a set minus itself should be empty, got %v", got.List())
+	}
+
+	if s.Size() != 4 || u.Size() != 3 {
+		t.Error("Operations should not modify the sets")
+	}
+}
@@ -0,0 +1,39 @@
+package set
+
+// ReadOnlySet is the read-only subset of Interface.
+type ReadOnlySet interface {
+	Has(items ...interface{}) bool
+	Size() int
+	IsEmpty() bool
+	IsEqual(s Interface) bool
+	IsSubset(s Interface) bool
+	IsSuperset(s Interface) bool
+	Each(func(interface{}) bool)
+	String() string
+	List() []interface{}
+	Copy() Interface
+}
+
+// view wraps a Set and only exposes its read methods, so it can't be converted
+// back to a mutable set.
+type view struct {
+	s *Set
+}
+
+// View returns a read-only view of s. The view shares the items and the lock
+// of s, so no copy is made and it reflects all changes that are made to s
+// afterwards. Use Copy() if an independent snapshot is needed.
+func (s *Set) View() ReadOnlySet {
+	return view{s: s}
+}
+
+func (v view) Has(items ...interface{}) bool { return v.s.Has(items...) }
+func (v view) Size() int                     { return v.s.Size() }
+func (v view) IsEmpty() bool                 { return v.s.IsEmpty() }
+func (v view) IsEqual(t Interface) bool      { return v.s.IsEqual(t) }
+func (v view) IsSubset(t Interface) bool     { return v.s.IsSubset(t) }
+func (v view) IsSuperset(t Interface) bool   { return v.s.IsSuperset(t) }
+func (v view) Each(f func(interface{}) bool) { v.s.Each(f) }
+func (v view) String() string                { return v.s.String() }
+func (v view) List() []interface{}           { return v.s.List() }
+func (v view) Copy() Interface               { return v.s.Copy() }
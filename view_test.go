@@ -0,0 +1,29 @@
+package set
+
+import "testing"
+
+func TestSet_View(t *testing.T) {
+	s := newTS()
+	s.Add("1", "2", "3")
+
+	v := s.View()
+	if _, ok := v.(Interface); ok {
+		t.Error("View: a view should not implement the mutating Interface")
+	}
+
+	if v.Size() != 3 || !v.Has("1", "2", "3") || !v.IsEqual(s) {
+		t.Error("View: view should have the same items as the set")
+	}
+
+	s.Add("4")
+	s.Remove("1")
+	if v.Size() != 3 || !v.Has("4") || v.Has("1") {
+		t.Error("View: view should reflect changes to the underlying set")
+	}
+
+	c := v.Copy()
+	c.Add("5")
+	if v.Has("5") {
+		t.Error("View: modifying a copy of the view should not modify the set")
+	}
+}